@@ -0,0 +1,26 @@
+// Maps AWS CloudTrail records (https://docs.aws.amazon.com/awscloudtrail/latest/userguide/cloudtrail-event-reference-record-contents.html)
+// to the Elastic Common Schema (ECS).
+local sub = import '../../substation.libsonnet';
+
+{
+  fields: [
+    { key: 'eventTime', set_key: '\\@timestamp', time: { format: 'rfc3339', set_format: 'rfc3339' } },
+    { key: 'eventID', set_key: 'event.id' },
+    { key: 'eventName', set_key: 'event.action' },
+    { key: 'eventSource', set_key: 'event.provider' },
+    { key: 'awsRegion', set_key: 'cloud.region' },
+    { key: 'recipientAccountId', set_key: 'cloud.account.id' },
+    { key: 'sourceIPAddress', set_key: 'source.address' },
+    { key: 'userAgent', set_key: 'user_agent.original' },
+    { key: 'userIdentity.arn', set_key: 'user.id' },
+    { key: 'userIdentity.userName', set_key: 'user.name' },
+    { key: 'errorCode', set_key: 'error.code' },
+    { key: 'errorMessage', set_key: 'error.message' },
+    { set_key: 'cloud.provider', default: 'aws' },
+    { set_key: 'event.kind', default: 'event' },
+  ],
+  processor(options={}, settings={}): sub.interfaces.processor.map(
+    options=std.mergePatch({ fields: $.fields }, options),
+    settings=settings,
+  ),
+}
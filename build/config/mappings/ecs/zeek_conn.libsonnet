@@ -0,0 +1,27 @@
+// Maps Zeek conn logs (https://docs.zeek.org/en/master/logs/conn.html) to
+// the Elastic Common Schema (ECS).
+local sub = import '../../substation.libsonnet';
+
+{
+  fields: [
+    { key: 'ts', set_key: '\\@timestamp', time: { format: 'unix', set_format: 'rfc3339' } },
+    { key: 'uid', set_key: 'event.id' },
+    { key: 'id\\.orig_h', set_key: 'source.ip' },
+    { key: 'id\\.orig_p', set_key: 'source.port', convert: 'int' },
+    { key: 'id\\.resp_h', set_key: 'destination.ip' },
+    { key: 'id\\.resp_p', set_key: 'destination.port', convert: 'int' },
+    { key: 'proto', set_key: 'network.transport' },
+    { key: 'service', set_key: 'network.protocol' },
+    { key: 'orig_bytes', set_key: 'source.bytes', convert: 'int' },
+    { key: 'resp_bytes', set_key: 'destination.bytes', convert: 'int' },
+    { key: 'orig_pkts', set_key: 'source.packets', convert: 'int' },
+    { key: 'resp_pkts', set_key: 'destination.packets', convert: 'int' },
+    { key: 'conn_state', set_key: 'zeek.connection.state' },
+    { set_key: 'event.kind', default: 'event' },
+    { set_key: 'event.category', default: ['network'] },
+  ],
+  processor(options={}, settings={}): sub.interfaces.processor.map(
+    options=std.mergePatch({ fields: $.fields }, options),
+    settings=settings,
+  ),
+}
@@ -4,6 +4,9 @@
       content: {
         options: { type: null },
       },
+      expr: {
+        options: { expression: null },
+      },
       for_each: {
         options: { type: null, inspector: null },
       },
@@ -58,6 +61,9 @@
       aggregate: {
         options: { key: null, separator: null, max_count: 1000, max_size: 10000 },
       },
+      avro: {
+        options: { direction: null, format: null, schema: null, compression: 'null' },
+      },
       aws_dynamodb: {
         options: { table: null, key_condition_expression: null, limit: 1, scan_index_forward: false },
       },
@@ -67,21 +73,48 @@
       base64: {
         options: { direction: null },
       },
+      cache: {
+        options: { processor: null, prefix: null, offset_ttl: null, kv_options: null },
+      },
       capture: {
         options: { expression: null, type: 'find', count: -1 },
       },
       case: {
         options: { type: null },
       },
+      community_id: {
+        options: { source_ip_key: null, destination_ip_key: null, source_port_key: null, destination_port_key: null, protocol_key: null, seed: 0 },
+      },
+      compress: {
+        options: { direction: null, type: null, level: null },
+      },
       convert: {
         options: { type: null },
       },
+      correlate: {
+        options: { type: null, close: null, offset_ttl: null, max_count: 1000, prefix: null, kv_options: null },
+      },
+      dedupe: {
+        options: { keys: null, action: null, offset_ttl: null, prefix: null, kv_options: null },
+      },
       dns: {
-        options: { type: null, timeout: 1000 },
+        options: { type: null, timeout: 1000, resolver: null, kv_options: null },
       },
       domain: {
         options: { type: null },
       },
+      encode: {
+        options: { direction: null, type: null, recursive: false, max_depth: 10 },
+      },
+      encrypt: {
+        options: { direction: null, key_id: null, keys: null, kms_key_id: null },
+      },
+      error_handler: {
+        options: { processors: null, fallback: [] },
+      },
+      expr: {
+        options: { expression: null },
+      },
       flatten: {
         options: { deep: true },
       },
@@ -98,11 +131,14 @@
         options: { algorithm: 'sha256' },
       },
       http: {
-        options: { method: 'get', url: null, headers: null, key: null },
+        options: { method: 'get', url: null, headers: null, key: null, body_key: null, body_template: null, response_key: null, skip_status: null, error_status: null, rate_limit: null, burst: null, offset_ttl: null, kv_options: null },
       },
       insert: {
         options: { value: null },
       },
+      ip: {
+        options: { type: null, cidrs: null, ipv4_prefix: 24, ipv6_prefix: 48 },
+      },
       ip_database: {
         options: { type: null, settings: null },
       },
@@ -115,25 +151,64 @@
       kv_store: {
         options: { type: null, prefix: null, offset_ttl: null, kv_options: null },
       },
+      map: {
+        options: { fields: null, drop_unmapped: false },
+      },
       math: {
         options: { operation: null },
       },
+      msgpack: {
+        options: { direction: null },
+      },
       pipeline: {
         options: { processors: null },
       },
       pretty_print: {
         options: { direction: null },
       },
+      protobuf: {
+        options: { direction: null, descriptor: null, message: null },
+      },
+      redact: {
+        options: { detectors: null, custom: null, action: null, character: '*', placeholder: '[REDACTED]', report_key: null },
+      },
       replace: {
         options: { old: null, new: null, count: -1 },
       },
+      sample: {
+        options: { type: null, rate: null, limit: null, burst: null, capacity: null, kv_options: null },
+      },
+      script: {
+        options: { script: null, batch: false, max_steps: 1000000, timeout: 1000 },
+      },
       split: {
         options: { separator: null },
       },
+      switch: {
+        options: { cases: null, default: [] },
+      },
+      template: {
+        options: { template: null },
+      },
       time: {
-        options: { format: null, location: null, set_format: $.defaults.processor.time.set_format, set_location: null },
+        options: { format: null, formats: null, location: null, set_format: $.defaults.processor.time.set_format, set_location: null, offset: null, truncate: null, diff_key: null },
         set_format: '2006-01-02T15:04:05.000000Z',
       },
+      tokenize: {
+        options: { direction: 'to', algorithm: 'hmac_sha256', secret: null, fields: null, prefix: null, length: null, vault: null, offset_ttl: null },
+      },
+      url: {
+        options: { type: null },
+      },
+      user_agent: {
+        options: { database: null, capacity: 1024 },
+      },
+      wasm: {
+        options: { module: null, max_memory: 64, timeout: 1000 },
+      },
+      window: {
+        options: { type: null, size: null, step: null, time_key: null, time_format: null, group_keys: null, statistics: null },
+      },
     },
     sink: {
       aws_dynamodb: {
@@ -226,6 +301,16 @@
         type: 'content',
         settings: std.mergePatch({ options: opt }, s),
       },
+      expr(options=$.defaults.inspector.expr.options,
+           settings=$.interfaces.inspector.settings): {
+        local opt = std.mergePatch($.defaults.inspector.expr.options, options),
+
+        assert $.helpers.inspector.validate(settings) : 'invalid inspector settings',
+        local s = std.mergePatch($.interfaces.inspector.settings, settings),
+
+        type: 'expr',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       for_each(options=$.defaults.inspector.for_each.options,
                settings=$.interfaces.inspector.settings): {
         local opt = std.mergePatch($.defaults.processor.inspector.for_each.options, options),
@@ -308,6 +393,14 @@
         type: 'aggregate',
         settings: std.mergePatch({ options: opt }, s),
       },
+      avro(options=$.defaults.processor.avro.options,
+           settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.avro.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'avro',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       aws_dynamodb(options=$.defaults.processor.aws_dynamodb.options,
                    settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.aws_dynamodb.options, options),
@@ -333,6 +426,14 @@
         type: 'base64',
         settings: std.mergePatch({ options: opt }, s),
       },
+      cache(options=$.defaults.processor.cache.options,
+            settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.cache.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'cache',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       capture(options=$.defaults.processor.capture.options,
               settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.capture.options, options),
@@ -349,6 +450,22 @@
         type: 'case',
         settings: std.mergePatch({ options: opt }, s),
       },
+      community_id(options=$.defaults.processor.community_id.options,
+                   settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.community_id.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'community_id',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      compress(options=$.defaults.processor.compress.options,
+               settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.compress.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'compress',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       convert(options=$.defaults.processor.convert.options,
               settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.convert.options, options),
@@ -363,6 +480,22 @@
         type: 'copy',
         settings: s,
       },
+      correlate(options=$.defaults.processor.correlate.options,
+                settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.correlate.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'correlate',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      dedupe(options=$.defaults.processor.dedupe.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.dedupe.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'dedupe',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       delete(settings=$.interfaces.processor.settings): {
         local s = std.mergePatch($.interfaces.processor.settings, settings),
 
@@ -391,12 +524,44 @@
         type: 'drop',
         settings: s,
       },
+      encode(options=$.defaults.processor.encode.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.encode.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'encode',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      encrypt(options=$.defaults.processor.encrypt.options,
+              settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.encrypt.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'encrypt',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      error_handler(options=$.defaults.processor.error_handler.options,
+                    settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.error_handler.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'error_handler',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       expand(settings=$.interfaces.processor.settings): {
         local s = std.mergePatch($.interfaces.processor.settings, settings),
 
         type: 'expand',
         settings: s,
       },
+      expr(options=$.defaults.processor.expr.options,
+           settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.expr.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'expr',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       flatten(options=$.defaults.processor.flatten.options,
               settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.flatten.options, options),
@@ -453,6 +618,14 @@
         type: 'insert',
         settings: std.mergePatch({ options: opt }, s),
       },
+      ip(options=$.defaults.processor.ip.options,
+         settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.ip.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'ip',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       ip_database(options=$.defaults.processor.ip_database.options,
                   settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.ip_database.options, options),
@@ -485,6 +658,14 @@
         type: 'kv_store',
         settings: std.mergePatch({ options: opt }, s),
       },
+      map(options=$.defaults.processor.map.options,
+          settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.map.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'map',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       math(options=$.defaults.processor.math.options,
            settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.math.options, options),
@@ -493,6 +674,14 @@
         type: 'math',
         settings: std.mergePatch({ options: opt }, s),
       },
+      msgpack(options=$.defaults.processor.msgpack.options,
+              settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.msgpack.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'msgpack',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       pipeline(options=$.defaults.processor.pipeline.options,
                settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.pipeline.options, options),
@@ -509,6 +698,22 @@
         type: 'pretty_print',
         settings: std.mergePatch({ options: opt }, s),
       },
+      protobuf(options=$.defaults.processor.protobuf.options,
+               settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.protobuf.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'protobuf',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      redact(options=$.defaults.processor.redact.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.redact.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'redact',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       replace(options=$.defaults.processor.replace.options,
               settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.replace.options, options),
@@ -517,6 +722,22 @@
         type: 'replace',
         settings: std.mergePatch({ options: opt }, s),
       },
+      sample(options=$.defaults.processor.sample.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.sample.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'sample',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      script(options=$.defaults.processor.script.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.script.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'script',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       split(options=$.defaults.processor.split.options,
             settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.split.options, options),
@@ -525,6 +746,22 @@
         type: 'split',
         settings: std.mergePatch({ options: opt }, s),
       },
+      switch(options=$.defaults.processor.switch.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.switch.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'switch',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      template(options=$.defaults.processor.template.options,
+               settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.template.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'template',
+        settings: std.mergePatch({ options: opt }, s),
+      },
       time(options=$.defaults.processor.time.options,
            settings=$.interfaces.processor.settings): {
         local opt = std.mergePatch($.defaults.processor.time.options, options),
@@ -533,6 +770,46 @@
         type: 'time',
         settings: std.mergePatch({ options: opt }, s),
       },
+      tokenize(options=$.defaults.processor.tokenize.options,
+               settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.tokenize.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'tokenize',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      url(options=$.defaults.processor.url.options,
+          settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.url.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'url',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      user_agent(options=$.defaults.processor.user_agent.options,
+                 settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.user_agent.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'user_agent',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      wasm(options=$.defaults.processor.wasm.options,
+           settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.wasm.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'wasm',
+        settings: std.mergePatch({ options: opt }, s),
+      },
+      window(options=$.defaults.processor.window.options,
+             settings=$.interfaces.processor.settings): {
+        local opt = std.mergePatch($.defaults.processor.window.options, options),
+        local s = std.mergePatch($.interfaces.processor.settings, settings),
+
+        type: 'window',
+        settings: std.mergePatch({ options: opt }, s),
+      },
     },
     // mirrors interfaces from the internal/sink package
     sink: {
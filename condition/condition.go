@@ -48,6 +48,8 @@ func NewInspector(ctx context.Context, cfg config.Config) (Inspector, error) {
 		return newInspCondition(ctx, cfg)
 	case "content":
 		return newInspContent(ctx, cfg)
+	case "expr":
+		return newInspExpr(ctx, cfg)
 	case "for_each":
 		return newInspForEach(ctx, cfg)
 	case "ip":
@@ -0,0 +1,65 @@
+package condition
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/expr"
+)
+
+// expr evaluates data using an expression (https://expr-lang.org/) that
+// returns a boolean. Expressions reference values in an object by their key
+// (e.g., status >= 400 && user != "root") and use the same language as the
+// expr processor.
+//
+// This inspector supports the object handling pattern.
+type inspExpr struct {
+	condition
+	Options inspExprOptions `json:"options"`
+
+	program *expr.Program
+}
+
+type inspExprOptions struct {
+	// Expression is the expression used during inspection.
+	Expression string `json:"expression"`
+}
+
+// Creates a new expr inspector.
+func newInspExpr(_ context.Context, cfg config.Config) (c inspExpr, err error) {
+	if err = config.Decode(cfg.Settings, &c); err != nil {
+		return inspExpr{}, err
+	}
+
+	// error early if required options are missing
+	if c.Options.Expression == "" {
+		return inspExpr{}, fmt.Errorf("condition: expr: options %+v: %v", c.Options, errors.ErrMissingRequiredOption)
+	}
+
+	c.program, err = expr.CompileBool(c.Options.Expression)
+	if err != nil {
+		return inspExpr{}, fmt.Errorf("condition: expr: %v", err)
+	}
+
+	return c, nil
+}
+
+func (c inspExpr) String() string {
+	return toString(c)
+}
+
+// Inspect evaluates encapsulated data with the expr inspector.
+func (c inspExpr) Inspect(ctx context.Context, capsule config.Capsule) (output bool, err error) {
+	matched, err := c.program.EvalBool(capsule)
+	if err != nil {
+		return false, fmt.Errorf("condition: expr: %v", err)
+	}
+
+	if c.Negate {
+		return !matched, nil
+	}
+
+	return matched, nil
+}
@@ -0,0 +1,120 @@
+package condition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var _ Inspector = inspExpr{}
+
+var exprTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected bool
+}{
+	{
+		"pass",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"expression": `status >= 400 && user != "root"`,
+				},
+			},
+		},
+		[]byte(`{"status":404,"user":"foo"}`),
+		true,
+	},
+	{
+		"fail",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"expression": `status >= 400 && user != "root"`,
+				},
+			},
+		},
+		[]byte(`{"status":404,"user":"root"}`),
+		false,
+	},
+	{
+		"!pass",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"negate": true,
+				"options": map[string]interface{}{
+					"expression": `"admin" in groups`,
+				},
+			},
+		},
+		[]byte(`{"groups":["admin","users"]}`),
+		false,
+	},
+	{
+		"missing",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"expression": `(foo?.bar ?? "") == "baz"`,
+				},
+			},
+		},
+		[]byte(`{"qux":"quux"}`),
+		false,
+	},
+}
+
+func TestExpr(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range exprTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			insp, err := newInspExpr(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			check, err := insp.Inspect(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if test.expected != check {
+				t.Errorf("expected %v, got %v", test.expected, check)
+			}
+		})
+	}
+}
+
+func benchmarkExpr(b *testing.B, inspector inspExpr, capsule config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = inspector.Inspect(ctx, capsule)
+	}
+}
+
+func BenchmarkExpr(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range exprTests {
+		insp, err := newInspExpr(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkExpr(b, insp, capsule)
+			},
+		)
+	}
+}
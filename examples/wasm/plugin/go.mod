@@ -0,0 +1,3 @@
+module github.com/brexhq/substation/examples/wasm/plugin
+
+go 1.24
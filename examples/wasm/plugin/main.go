@@ -0,0 +1,66 @@
+// This is an example WebAssembly plugin for the wasm processor. The plugin
+// adds the size of the data (in bytes) to JSON objects and returns an error
+// if the data is not a JSON object.
+//
+// Build the plugin with Go 1.24 or later:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o plugin.wasm .
+package main
+
+import (
+	"encoding/json"
+	"unsafe"
+)
+
+// buffers prevents memory allocated by the host from being garbage collected.
+// Plugins are instantiated for each capsule, so memory is not reused.
+var buffers [][]byte
+
+//go:wasmimport substation set_data
+func setData(ptr, size uint32)
+
+//go:wasmimport substation set_error
+func setError(ptr, size uint32)
+
+//go:wasmexport alloc
+func alloc(size uint32) uint32 {
+	b := make([]byte, size+1)
+	buffers = append(buffers, b)
+
+	return uint32(uintptr(unsafe.Pointer(&b[0])))
+}
+
+//go:wasmexport process
+func process(dataPtr, dataLen, metadataPtr, metadataLen uint32) uint32 {
+	data := unsafe.Slice((*byte)(unsafe.Pointer(uintptr(dataPtr))), dataLen)
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		returnBytes(setError, []byte(err.Error()))
+		return 1
+	}
+
+	obj["size"] = len(data)
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		returnBytes(setError, []byte(err.Error()))
+		return 1
+	}
+
+	returnBytes(setData, b)
+	return 0
+}
+
+// returnBytes passes bytes to a host function.
+func returnBytes(fn func(uint32, uint32), b []byte) {
+	if len(b) == 0 {
+		fn(0, 0)
+		return
+	}
+
+	fn(uint32(uintptr(unsafe.Pointer(&b[0]))), uint32(len(b)))
+}
+
+// main is required, but is not called by the wasm processor.
+func main() {}
@@ -3,10 +3,12 @@ module github.com/brexhq/substation
 go 1.19
 
 require (
+	github.com/andybalholm/brotli v1.0.4
 	github.com/aws/aws-lambda-go v1.36.1
 	github.com/aws/aws-sdk-go v1.44.170
 	github.com/aws/aws-xray-sdk-go v1.8.0
 	github.com/awslabs/kinesis-aggregation/go v0.0.0-20221116143518-d545ec06b62c
+	github.com/expr-lang/expr v1.16.9
 	github.com/golang/protobuf v1.5.2
 	github.com/google/go-jsonnet v0.19.1
 	github.com/google/uuid v1.3.0
@@ -16,22 +18,31 @@ require (
 	github.com/itchyny/gojq v0.12.11
 	github.com/jshlbrd/go-aggregate v0.1.1
 	github.com/klauspost/compress v1.16.4
+	github.com/linkedin/goavro/v2 v2.15.0
 	github.com/oschwald/geoip2-golang v1.8.0
 	github.com/oschwald/maxminddb-golang v1.10.0
+	github.com/pierrec/lz4/v4 v4.1.18
 	github.com/sirupsen/logrus v1.9.0
+	github.com/tetratelabs/wazero v1.1.0
 	github.com/tidwall/gjson v1.14.4
 	github.com/tidwall/sjson v1.2.5
+	github.com/ua-parser/uap-go v0.0.0-20230823213814-f77b3e91e9dc
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	go.starlark.net v0.0.0-20230525235612-a134d8f9ddca
 	go.uber.org/goleak v1.2.0
 	golang.org/x/exp v0.0.0-20230310171629-522b1b587ee0
 	golang.org/x/net v0.7.0
 	golang.org/x/sync v0.1.0
+	golang.org/x/text v0.7.0
+	golang.org/x/time v0.3.0
 	google.golang.org/grpc v1.51.0
 	google.golang.org/protobuf v1.30.0
 )
 
 require (
-	github.com/andybalholm/brotli v1.0.4 // indirect
+	github.com/golang/snappy v0.0.1 // indirect
 	github.com/hashicorp/go-cleanhttp v0.5.2 // indirect
+	github.com/hashicorp/golang-lru v0.5.4 // indirect
 	github.com/itchyny/timefmt-go v0.1.5 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
@@ -39,9 +50,9 @@ require (
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.43.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // indirect
 	golang.org/x/sys v0.5.0 // indirect
-	golang.org/x/text v0.7.0 // indirect
 	google.golang.org/genproto v0.0.0-20221227171554-f9683d7f8bef // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
@@ -0,0 +1,89 @@
+package kms
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// New returns a configured KMS client.
+func New() *kms.KMS {
+	conf := aws.NewConfig()
+
+	// provides forward compatibility for the Go SDK to support env var configuration settings
+	// https://github.com/aws/aws-sdk-go/issues/4207
+	max, found := os.LookupEnv("AWS_MAX_ATTEMPTS")
+	if found {
+		m, err := strconv.Atoi(max)
+		if err != nil {
+			panic(err)
+		}
+
+		conf = conf.WithMaxRetries(m)
+	}
+
+	c := kms.New(
+		session.Must(session.NewSession()),
+		conf,
+	)
+
+	if _, ok := os.LookupEnv("AWS_XRAY_DAEMON_ADDRESS"); ok {
+		xray.AWS(c.Client)
+	}
+
+	return c
+}
+
+// API wraps the KMS API interface.
+type API struct {
+	Client kmsiface.KMSAPI
+}
+
+// Setup creates a new KMS client.
+func (a *API) Setup() {
+	a.Client = New()
+}
+
+// IsEnabled returns true if the client is enabled and ready for use.
+func (a *API) IsEnabled() bool {
+	return a.Client != nil
+}
+
+// GenerateDataKey is a convenience wrapper for generating a 256-bit data key.
+// The data key is returned as plaintext and as ciphertext that is encrypted
+// by the KMS key.
+func (a *API) GenerateDataKey(ctx aws.Context, keyID string) (plaintext, ciphertext []byte, err error) {
+	resp, err := a.Client.GenerateDataKeyWithContext(
+		ctx,
+		&kms.GenerateDataKeyInput{
+			KeyId:   aws.String(keyID),
+			KeySpec: aws.String(kms.DataKeySpecAes256),
+		})
+	if err != nil {
+		return nil, nil, fmt.Errorf("generatedatakey key %s: %v", keyID, err)
+	}
+
+	return resp.Plaintext, resp.CiphertextBlob, nil
+}
+
+// Decrypt is a convenience wrapper for decrypting ciphertext that was
+// encrypted by a KMS key.
+func (a *API) Decrypt(ctx aws.Context, keyID string, ciphertext []byte) ([]byte, error) {
+	resp, err := a.Client.DecryptWithContext(
+		ctx,
+		&kms.DecryptInput{
+			KeyId:          aws.String(keyID),
+			CiphertextBlob: ciphertext,
+		})
+	if err != nil {
+		return nil, fmt.Errorf("decrypt key %s: %v", keyID, err)
+	}
+
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,99 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+type mockedGenerateDataKey struct {
+	kmsiface.KMSAPI
+	Resp kms.GenerateDataKeyOutput
+}
+
+func (m mockedGenerateDataKey) GenerateDataKeyWithContext(ctx aws.Context, input *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	return &m.Resp, nil
+}
+
+func TestGenerateDataKey(t *testing.T) {
+	tests := []struct {
+		resp       kms.GenerateDataKeyOutput
+		plaintext  []byte
+		ciphertext []byte
+	}{
+		{
+			resp: kms.GenerateDataKeyOutput{
+				Plaintext:      []byte("foo"),
+				CiphertextBlob: []byte("bar"),
+			},
+			plaintext:  []byte("foo"),
+			ciphertext: []byte("bar"),
+		},
+	}
+
+	ctx := context.TODO()
+
+	for _, test := range tests {
+		a := API{
+			mockedGenerateDataKey{Resp: test.resp},
+		}
+
+		plaintext, ciphertext, err := a.GenerateDataKey(ctx, "")
+		if err != nil {
+			t.Fatalf("%d, unexpected error", err)
+		}
+
+		if !bytes.Equal(plaintext, test.plaintext) {
+			t.Errorf("expected %s, got %s", test.plaintext, plaintext)
+		}
+
+		if !bytes.Equal(ciphertext, test.ciphertext) {
+			t.Errorf("expected %s, got %s", test.ciphertext, ciphertext)
+		}
+	}
+}
+
+type mockedDecrypt struct {
+	kmsiface.KMSAPI
+	Resp kms.DecryptOutput
+}
+
+func (m mockedDecrypt) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	return &m.Resp, nil
+}
+
+func TestDecrypt(t *testing.T) {
+	tests := []struct {
+		resp     kms.DecryptOutput
+		expected []byte
+	}{
+		{
+			resp: kms.DecryptOutput{
+				Plaintext: []byte("foo"),
+			},
+			expected: []byte("foo"),
+		},
+	}
+
+	ctx := context.TODO()
+
+	for _, test := range tests {
+		a := API{
+			mockedDecrypt{Resp: test.resp},
+		}
+
+		resp, err := a.Decrypt(ctx, "", nil)
+		if err != nil {
+			t.Fatalf("%d, unexpected error", err)
+		}
+
+		if !bytes.Equal(resp, test.expected) {
+			t.Errorf("expected %s, got %s", test.expected, resp)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+// package dns provides a DNS client that supports UDP, TCP, and DNS over
+// HTTPS (DoH) resolvers. Unlike the standard library's resolver, the client
+// returns complete resource records (including TTLs) for any record type.
+package dns
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/brexhq/substation/internal/http"
+)
+
+// errInvalidResponse is returned when a DNS server returns a response that
+// does not match the query.
+var errInvalidResponse = fmt.Errorf("invalid response")
+
+// defaultResolver is used if there are no resolvers in /etc/resolv.conf.
+const defaultResolver = "127.0.0.1:53"
+
+// Client queries a DNS resolver.
+type Client struct {
+	// network is one of udp, tcp, or https.
+	network string
+	// addr is a host and port for udp and tcp, or a URL for https.
+	addr string
+	http http.HTTP
+}
+
+// New returns a client that queries a resolver. The resolver must be one of:
+//
+// - empty: the first nameserver in /etc/resolv.conf is used over UDP
+//
+// - host or host:port: the resolver is queried over UDP
+//
+// - udp://host:port: the resolver is queried over UDP (responses that are
+// truncated are retried over TCP)
+//
+// - tcp://host:port: the resolver is queried over TCP
+//
+// - https://host/path: the resolver is queried using DNS over HTTPS (RFC 8484)
+//
+// If the port is not provided, then it defaults to 53.
+func New(resolver string) (*Client, error) {
+	if resolver == "" {
+		resolver = systemResolver()
+	}
+
+	if strings.HasPrefix(resolver, "https://") {
+		c := &Client{network: "https", addr: resolver}
+		c.http.Setup()
+		c.http.Client.Logger = nil
+
+		return c, nil
+	}
+
+	network := "udp"
+	if i := strings.Index(resolver, "://"); i != -1 {
+		network = resolver[:i]
+		resolver = resolver[i+3:]
+	}
+
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("dns: resolver %s: unsupported network %s", resolver, network)
+	}
+
+	if _, _, err := net.SplitHostPort(resolver); err != nil {
+		resolver = net.JoinHostPort(strings.Trim(resolver, "[]"), "53")
+	}
+
+	return &Client{network: network, addr: resolver}, nil
+}
+
+// Query sends a query to the resolver and returns the answers in the response.
+// An error is returned if the response code is not success (e.g., the name
+// does not exist).
+func (c *Client) Query(ctx context.Context, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+
+	n, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, fmt.Errorf("dns: name %s: %v", name, err)
+	}
+
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, fmt.Errorf("dns: %v", err)
+	}
+
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			//nolint: gosec // the ID does not need to be cryptographically random
+			ID:               uint16(rand.Uint32()),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{
+			{Name: n, Type: qtype, Class: dnsmessage.ClassINET},
+		},
+		Additionals: []dnsmessage.Resource{
+			{Header: opt, Body: &dnsmessage.OPTResource{}},
+		},
+	}
+
+	// DoH queries use an ID of 0 to improve caching (RFC 8484 section 4.1)
+	if c.network == "https" {
+		query.Header.ID = 0
+	}
+
+	resp, err := c.exchange(ctx, c.network, query)
+	if err != nil {
+		return nil, fmt.Errorf("dns: name %s type %s: %v", name, qtype, err)
+	}
+
+	// truncated UDP responses are retried over TCP (RFC 7766 section 5)
+	if resp.Header.Truncated && c.network == "udp" {
+		resp, err = c.exchange(ctx, "tcp", query)
+		if err != nil {
+			return nil, fmt.Errorf("dns: name %s type %s: %v", name, qtype, err)
+		}
+	}
+
+	if resp.Header.ID != query.Header.ID || len(resp.Questions) != 1 || resp.Questions[0] != query.Questions[0] {
+		return nil, fmt.Errorf("dns: name %s type %s: %v", name, qtype, errInvalidResponse)
+	}
+
+	if resp.Header.RCode != dnsmessage.RCodeSuccess {
+		return nil, fmt.Errorf("dns: name %s type %s: %s", name, qtype, resp.Header.RCode)
+	}
+
+	return resp.Answers, nil
+}
+
+func (c *Client) exchange(ctx context.Context, network string, query dnsmessage.Message) (dnsmessage.Message, error) {
+	b, err := query.Pack()
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	var resp []byte
+	switch network {
+	case "https":
+		resp, err = c.exchangeHTTPS(ctx, b)
+	default:
+		resp, err = c.exchangeConn(ctx, network, b)
+	}
+
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(resp); err != nil {
+		return dnsmessage.Message{}, err
+	}
+
+	return msg, nil
+}
+
+func (c *Client) exchangeConn(ctx context.Context, network string, query []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, c.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, 65535)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+
+		return buf[:n], nil
+	}
+
+	// TCP messages are prefixed with their length (RFC 1035 section 4.2.2)
+	msg := binary.BigEndian.AppendUint16(make([]byte, 0, len(query)+2), uint16(len(query)))
+	if _, err := conn.Write(append(msg, query...)); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func (c *Client) exchangeHTTPS(ctx context.Context, query []byte) ([]byte, error) {
+	resp, err := c.http.Post(ctx, c.addr, query,
+		http.Header{Key: "Content-Type", Value: "application/dns-message"},
+		http.Header{Key: "Accept", Value: "application/dns-message"},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// ReverseName returns the name used for reverse lookups (PTR queries) of an
+// IP address (e.g., 1.0.0.127.in-addr.arpa. for 127.0.0.1).
+func ReverseName(ip string) (string, error) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return "", fmt.Errorf("dns: %v", err)
+	}
+
+	addr = addr.Unmap()
+	b := addr.AsSlice()
+
+	var buf bytes.Buffer
+	for i := len(b) - 1; i >= 0; i-- {
+		if addr.Is4() {
+			fmt.Fprintf(&buf, "%d.", b[i])
+			continue
+		}
+
+		fmt.Fprintf(&buf, "%x.%x.", b[i]&0xf, b[i]>>4)
+	}
+
+	if addr.Is4() {
+		buf.WriteString("in-addr.arpa.")
+	} else {
+		buf.WriteString("ip6.arpa.")
+	}
+
+	return buf.String(), nil
+}
+
+// systemResolver returns the first nameserver in /etc/resolv.conf.
+func systemResolver() string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return defaultResolver
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(fields[1], "53")
+		}
+	}
+
+	return defaultResolver
+}
@@ -0,0 +1,256 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testRecords are the records returned by testResponse.
+var testRecords = map[string][]dnsmessage.ResourceBody{
+	"example.com. TypeA": {
+		&dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}},
+	},
+	"example.com. TypeMX": {
+		&dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx.example.com.")},
+	},
+	"34.216.184.93.in-addr.arpa. TypePTR": {
+		&dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("example.com.")},
+	},
+	"truncated.example.com. TypeA": {
+		&dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+	},
+}
+
+// testResponse returns a response to a query. Queries for
+// truncated.example.com are truncated over UDP.
+func testResponse(t *testing.T, query []byte, udp bool) []byte {
+	var q dnsmessage.Message
+	if err := q.Unpack(query); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:       q.Header.ID,
+			Response: true,
+		},
+		Questions: q.Questions,
+	}
+
+	question := q.Questions[0]
+	records, ok := testRecords[question.Name.String()+" "+question.Type.String()]
+	switch {
+	case !ok:
+		resp.Header.RCode = dnsmessage.RCodeNameError
+	case udp && question.Name.String() == "truncated.example.com.":
+		resp.Header.Truncated = true
+	default:
+		for _, r := range records {
+			resp.Answers = append(resp.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{
+					Name:  question.Name,
+					Type:  question.Type,
+					Class: dnsmessage.ClassINET,
+					TTL:   300,
+				},
+				Body: r,
+			})
+		}
+	}
+
+	b, err := resp.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// testServer starts UDP and TCP servers that respond to queries on the same
+// port and returns their address.
+func testServer(t *testing.T) string {
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tcp.Close() })
+
+	udp, err := net.ListenPacket("udp", tcp.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { udp.Close() })
+
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			_, _ = udp.WriteTo(testResponse(t, buf[:n], true), addr)
+		}
+	}()
+
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+
+			var length uint16
+			if err := binary.Read(conn, binary.BigEndian, &length); err != nil {
+				conn.Close()
+				continue
+			}
+
+			query := make([]byte, length)
+			if _, err := io.ReadFull(conn, query); err != nil {
+				conn.Close()
+				continue
+			}
+
+			resp := testResponse(t, query, false)
+			_, _ = conn.Write(append(binary.BigEndian.AppendUint16(nil, uint16(len(resp))), resp...))
+			conn.Close()
+		}
+	}()
+
+	return tcp.Addr().String()
+}
+
+func TestQuery(t *testing.T) {
+	addr := testServer(t)
+
+	doh := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/dns-message" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		query, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(testResponse(t, query, false))
+	}))
+	defer doh.Close()
+
+	var tests = []struct {
+		name     string
+		resolver string
+		query    string
+		qtype    dnsmessage.Type
+		expected string
+	}{
+		{"udp", addr, "example.com", dnsmessage.TypeA, "93.184.216.34"},
+		{"udp scheme", "udp://" + addr, "example.com", dnsmessage.TypeMX, "mx.example.com."},
+		{"udp truncated", addr, "truncated.example.com", dnsmessage.TypeA, "127.0.0.1"},
+		{"tcp", "tcp://" + addr, "example.com.", dnsmessage.TypeA, "93.184.216.34"},
+		{"https", doh.URL, "example.com", dnsmessage.TypeA, "93.184.216.34"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := New(test.resolver)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// trust the test server's certificate
+			if c.network == "https" {
+				c.http.Client.HTTPClient = doh.Client()
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			answers, err := c.Query(ctx, test.query, test.qtype)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(answers) != 1 {
+				t.Fatalf("expected 1 answer, got %d", len(answers))
+			}
+
+			var got string
+			switch b := answers[0].Body.(type) {
+			case *dnsmessage.AResource:
+				got = net.IP(b.A[:]).String()
+			case *dnsmessage.MXResource:
+				got = b.MX.String()
+			}
+
+			if got != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, got)
+			}
+
+			if answers[0].Header.TTL != 300 {
+				t.Errorf("expected TTL 300, got %d", answers[0].Header.TTL)
+			}
+		})
+	}
+}
+
+func TestQueryNameError(t *testing.T) {
+	addr := testServer(t)
+
+	c, err := New(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Query(context.Background(), "missing.example.com", dnsmessage.TypeA); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("quic://127.0.0.1"); err == nil {
+		t.Error("expected error for unsupported network")
+	}
+
+	c, err := New("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.addr != "127.0.0.1:53" {
+		t.Errorf("expected 127.0.0.1:53, got %s", c.addr)
+	}
+}
+
+func TestReverseName(t *testing.T) {
+	var tests = []struct {
+		ip       string
+		expected string
+	}{
+		{"93.184.216.34", "34.216.184.93.in-addr.arpa."},
+		{"::ffff:93.184.216.34", "34.216.184.93.in-addr.arpa."},
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa."},
+	}
+
+	for _, test := range tests {
+		name, err := ReverseName(test.ip)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if name != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, name)
+		}
+	}
+}
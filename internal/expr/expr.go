@@ -0,0 +1,221 @@
+// package expr provides an expression language (https://expr-lang.org/) that
+// evaluates expressions against capsules. It is shared by the expr processor
+// and the expr inspector so that both support the same language.
+package expr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+	"github.com/expr-lang/expr/vm/runtime"
+
+	"github.com/brexhq/substation/config"
+)
+
+// Program is a compiled expression.
+//
+// Expressions reference values in an object by their key (e.g.,
+// bytes_in + bytes_out), and values that do not exist are nil. Expressions
+// are null-safe: arithmetic (+, -, *, /, %, **) with a nil value returns nil
+// and ordering comparisons (<, <=, >, >=) with a nil value return false.
+// Nil values can be replaced with the nil coalescing (??) and optional
+// chaining (?.) operators (e.g., (bytes_in ?? 0) + (bytes_out ?? 0)).
+//
+// Numbers that are integers (e.g., 10) are evaluated as integers and other
+// numbers (e.g., 1.5) are evaluated as floats, so integer operators and
+// functions (e.g., %) can be used on integer values.
+//
+// In addition to the language's built-in functions, expressions can use the
+// get function to retrieve values from data or metadata using the same keys
+// as processors and inspectors (e.g., get("!metadata foo")).
+type Program struct {
+	program *vm.Program
+}
+
+// Compile returns a Program that evaluates an expression to any value.
+func Compile(expression string) (*Program, error) {
+	return compile(expression)
+}
+
+// CompileBool returns a Program that evaluates an expression to a boolean.
+func CompileBool(expression string) (*Program, error) {
+	return compile(expression, expr.AsBool())
+}
+
+// nilSafeOperators maps operators to functions that replace them. The
+// functions return nil (for arithmetic) or false (for comparisons) if
+// either operand is nil.
+var nilSafeOperators = map[string]string{
+	"+":  "$add",
+	"-":  "$subtract",
+	"*":  "$multiply",
+	"/":  "$divide",
+	"%":  "$modulo",
+	"**": "$exponent",
+	"^":  "$exponent",
+	"<":  "$less",
+	"<=": "$less_or_equal",
+	">":  "$more",
+	">=": "$more_or_equal",
+}
+
+// nilSafe is a visitor that replaces operators with nil-safe functions.
+type nilSafe struct{}
+
+func (nilSafe) Visit(node *ast.Node) {
+	n, ok := (*node).(*ast.BinaryNode)
+	if !ok {
+		return
+	}
+
+	fn, ok := nilSafeOperators[n.Operator]
+	if !ok {
+		return
+	}
+
+	ast.Patch(node, &ast.CallNode{
+		Callee:    &ast.IdentifierNode{Value: fn},
+		Arguments: []ast.Node{n.Left, n.Right},
+	})
+}
+
+func nilSafeArithmetic(fn func(a, b interface{}) interface{}) func(...interface{}) (interface{}, error) {
+	return func(params ...interface{}) (interface{}, error) {
+		if params[0] == nil || params[1] == nil {
+			return nil, nil
+		}
+
+		return fn(params[0], params[1]), nil
+	}
+}
+
+func nilSafeComparison(fn func(a, b interface{}) bool) func(...interface{}) (interface{}, error) {
+	return func(params ...interface{}) (interface{}, error) {
+		if params[0] == nil || params[1] == nil {
+			return false, nil
+		}
+
+		return fn(params[0], params[1]), nil
+	}
+}
+
+func compile(expression string, opts ...expr.Option) (*Program, error) {
+	arithmetic := new(func(interface{}, interface{}) interface{})
+	comparison := new(func(interface{}, interface{}) bool)
+
+	opts = append(opts,
+		// get is replaced when the expression is evaluated
+		expr.Env(map[string]interface{}{
+			"get": func(string) interface{} { return nil },
+		}),
+		expr.AllowUndefinedVariables(),
+		expr.Patch(nilSafe{}),
+		expr.Function("$add", nilSafeArithmetic(runtime.Add), arithmetic),
+		expr.Function("$subtract", nilSafeArithmetic(runtime.Subtract), arithmetic),
+		expr.Function("$multiply", nilSafeArithmetic(runtime.Multiply), arithmetic),
+		expr.Function("$divide", nilSafeArithmetic(func(a, b interface{}) interface{} {
+			return runtime.Divide(a, b)
+		}), arithmetic),
+		expr.Function("$modulo", nilSafeArithmetic(func(a, b interface{}) interface{} {
+			return runtime.Modulo(a, b)
+		}), arithmetic),
+		expr.Function("$exponent", nilSafeArithmetic(func(a, b interface{}) interface{} {
+			return runtime.Exponent(a, b)
+		}), arithmetic),
+		expr.Function("$less", nilSafeComparison(runtime.Less), comparison),
+		expr.Function("$less_or_equal", nilSafeComparison(runtime.LessOrEqual), comparison),
+		expr.Function("$more", nilSafeComparison(runtime.More), comparison),
+		expr.Function("$more_or_equal", nilSafeComparison(runtime.MoreOrEqual), comparison),
+	)
+
+	program, err := expr.Compile(expression, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %v", err)
+	}
+
+	return &Program{program}, nil
+}
+
+// Eval evaluates the program against a capsule. If the capsule's data is not
+// a JSON object, then only the get function can access the data.
+func (p *Program) Eval(capsule config.Capsule) (interface{}, error) {
+	env := make(map[string]interface{})
+	if data := bytes.TrimSpace(capsule.Data()); bytes.HasPrefix(data, []byte(`{`)) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		if err := dec.Decode(&env); err != nil {
+			return nil, fmt.Errorf("expr: %v", err)
+		}
+
+		for k, v := range env {
+			env[k] = number(v)
+		}
+	}
+
+	env["get"] = func(key string) interface{} {
+		return number(capsule.Get(key).Value())
+	}
+
+	value, err := expr.Run(p.program, env)
+	if err != nil {
+		return nil, fmt.Errorf("expr: %v", err)
+	}
+
+	return value, nil
+}
+
+// number recursively converts numbers to integers if they are integral,
+// otherwise they are converted to floats.
+func number(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+
+		f, _ := v.Float64()
+		return f
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int(v)
+		}
+
+		return v
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = number(val)
+		}
+
+		return v
+	case []interface{}:
+		for i, val := range v {
+			v[i] = number(val)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+// EvalBool evaluates the program against a capsule and returns a boolean.
+// Programs must be compiled with CompileBool.
+func (p *Program) EvalBool(capsule config.Capsule) (bool, error) {
+	value, err := p.Eval(capsule)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expr: %v is not a boolean", value)
+	}
+
+	return b, nil
+}
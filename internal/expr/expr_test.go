@@ -0,0 +1,131 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var evalTests = []struct {
+	name       string
+	expression string
+	test       []byte
+	expected   interface{}
+}{
+	{
+		"arithmetic",
+		"bytes_in + bytes_out",
+		[]byte(`{"bytes_in":10,"bytes_out":20}`),
+		30,
+	},
+	{
+		"float arithmetic",
+		"bytes_in + bytes_out",
+		[]byte(`{"bytes_in":10,"bytes_out":0.5}`),
+		10.5,
+	},
+	{
+		"integer modulo",
+		"port % 1000",
+		[]byte(`{"port":8443}`),
+		443,
+	},
+	{
+		"nil arithmetic",
+		"bytes_in + bytes_out",
+		[]byte(`{"bytes_in":10}`),
+		nil,
+	},
+	{
+		"nil comparison",
+		"status >= 400",
+		[]byte(`{"foo":"bar"}`),
+		false,
+	},
+	{
+		"functions",
+		"min(a, b) / 1000",
+		[]byte(`{"a":5000,"b":3000}`),
+		3.0,
+	},
+	{
+		"ternary",
+		`status >= 400 ? "error" : "ok"`,
+		[]byte(`{"status":404}`),
+		"error",
+	},
+	{
+		"strings",
+		`upper(user) + "@" + lower(domain)`,
+		[]byte(`{"user":"foo","domain":"EXAMPLE.COM"}`),
+		"FOO@example.com",
+	},
+	{
+		"arrays",
+		`len(filter(hosts, {# startsWith "prod"}))`,
+		[]byte(`{"hosts":["prod-1","dev-1","prod-2"]}`),
+		2,
+	},
+	{
+		"nil",
+		`(foo?.bar ?? 0) + 1`,
+		[]byte(`{"baz":1}`),
+		1,
+	},
+	{
+		"get",
+		`get("foo.bar")`,
+		[]byte(`{"foo":{"bar":"baz"}}`),
+		"baz",
+	},
+}
+
+func TestEval(t *testing.T) {
+	capsule := config.NewCapsule()
+
+	for _, test := range evalTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			program, err := Compile(test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := program.Eval(capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected %v (%T), got %v (%T)", test.expected, test.expected, result, result)
+			}
+		})
+	}
+}
+
+func TestEvalBool(t *testing.T) {
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`{"foo":"bar"}`))
+	if _, err := capsule.SetMetadata(map[string]interface{}{"baz": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := CompileBool(`foo == "bar" && get("!metadata baz") == 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := program.EvalBool(capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result {
+		t.Errorf("expected true, got false")
+	}
+
+	if _, err := CompileBool(`foo +`); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/hashicorp/go-retryablehttp"
@@ -60,6 +61,21 @@ func (h *HTTP) Get(ctx context.Context, url string, headers ...Header) (*http.Re
 
 // Post is a context-aware convenience function for making POST requests. This method optionally supports custom headers.
 func (h *HTTP) Post(ctx context.Context, url string, payload interface{}, headers ...Header) (resp *http.Response, err error) {
+	return h.send(ctx, "POST", url, payload, headers...)
+}
+
+// Put is a context-aware convenience function for making PUT requests. This method optionally supports custom headers.
+func (h *HTTP) Put(ctx context.Context, url string, payload interface{}, headers ...Header) (resp *http.Response, err error) {
+	return h.send(ctx, "PUT", url, payload, headers...)
+}
+
+// Patch is a context-aware convenience function for making PATCH requests. This method optionally supports custom headers.
+func (h *HTTP) Patch(ctx context.Context, url string, payload interface{}, headers ...Header) (resp *http.Response, err error) {
+	return h.send(ctx, "PATCH", url, payload, headers...)
+}
+
+// send makes requests that contain a payload.
+func (h *HTTP) send(ctx context.Context, method, url string, payload interface{}, headers ...Header) (resp *http.Response, err error) {
 	var tmp []byte
 
 	switch p := payload.(type) {
@@ -68,12 +84,12 @@ func (h *HTTP) Post(ctx context.Context, url string, payload interface{}, header
 	case string:
 		tmp = []byte(p)
 	default:
-		return nil, fmt.Errorf("http post URL %s: %v", url, errHTTPInvalidPayload)
+		return nil, fmt.Errorf("http %s URL %s: %v", strings.ToLower(method), url, errHTTPInvalidPayload)
 	}
 
-	req, err := retryablehttp.NewRequest("POST", url, tmp)
+	req, err := retryablehttp.NewRequest(method, url, tmp)
 	if err != nil {
-		return nil, fmt.Errorf("http post URL %s: %v", url, err)
+		return nil, fmt.Errorf("http %s URL %s: %v", strings.ToLower(method), url, err)
 	}
 	reqCtx := req.WithContext(ctx)
 
@@ -83,7 +99,7 @@ func (h *HTTP) Post(ctx context.Context, url string, payload interface{}, header
 
 	resp, err = h.Client.Do(reqCtx)
 	if err != nil {
-		return nil, fmt.Errorf("http post URL %s: %v", url, err)
+		return nil, fmt.Errorf("http %s URL %s: %v", strings.ToLower(method), url, err)
 	}
 
 	return resp, nil
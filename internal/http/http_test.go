@@ -47,6 +47,42 @@ func TestPost(t *testing.T) {
 	}
 }
 
+func TestPutPatch(t *testing.T) {
+	serv := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			_, _ = w.Write(append([]byte(r.Method+" "), body...))
+		}))
+	defer serv.Close()
+
+	ctx := context.TODO()
+
+	h := HTTP{
+		retryablehttp.NewClient(),
+	}
+
+	for method, send := range map[string]func(context.Context, string, interface{}, ...Header) (*http.Response, error){
+		"PUT":   h.Put,
+		"PATCH": h.Patch,
+	} {
+		resp, err := send(ctx, serv.URL, "foo")
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		expected := []byte(method + " foo")
+		if c := bytes.Compare(body, expected); c != 0 {
+			t.Errorf("expected %s, got %s", expected, body)
+		}
+	}
+}
+
 func TestGet(t *testing.T) {
 	tests := []struct {
 		expected []byte
@@ -0,0 +1,104 @@
+package ip
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"net/netip"
+)
+
+// IANA protocol numbers that are used by the Community ID flow hash.
+const (
+	ProtocolICMP   = 1
+	ProtocolTCP    = 6
+	ProtocolUDP    = 17
+	ProtocolICMPv6 = 58
+	ProtocolSCTP   = 132
+)
+
+// icmpTypes maps ICMP message types to the type of their counterpart (e.g.,
+// echo request to echo reply). Types that are not in this map are one-way.
+var icmpTypes = map[uint16]uint16{
+	0:  8,
+	8:  0,
+	9:  10,
+	10: 9,
+	13: 14,
+	14: 13,
+	15: 16,
+	16: 15,
+	17: 18,
+	18: 17,
+}
+
+// icmpv6Types maps ICMPv6 message types to the type of their counterpart.
+var icmpv6Types = map[uint16]uint16{
+	128: 129,
+	129: 128,
+	130: 131,
+	131: 130,
+	133: 134,
+	134: 133,
+	135: 136,
+	136: 135,
+	139: 140,
+	140: 139,
+	144: 145,
+	145: 144,
+}
+
+// CommunityID returns the version 1 Community ID flow hash of a network flow
+// (https://github.com/corelight/community-id-spec). For ICMP and ICMPv6, the
+// source and destination ports are the message type and code.
+//
+// The source and destination are ordered before the flow is hashed, so both
+// directions of a flow have the same Community ID.
+func CommunityID(seed uint16, src, dst netip.Addr, srcPort, dstPort uint16, protocol uint8) (string, error) {
+	// IPv4-mapped IPv6 addresses are hashed as IPv4
+	src, dst = src.Unmap(), dst.Unmap()
+	if !src.IsValid() || !dst.IsValid() || src.Is4() != dst.Is4() {
+		return "", ErrInvalidIPAddress
+	}
+
+	oneWay := false
+	switch protocol {
+	case ProtocolICMP:
+		srcPort, dstPort, oneWay = icmpPorts(icmpTypes, srcPort, dstPort)
+	case ProtocolICMPv6:
+		srcPort, dstPort, oneWay = icmpPorts(icmpv6Types, srcPort, dstPort)
+	}
+
+	s, d := src.AsSlice(), dst.AsSlice()
+	if !oneWay {
+		if c := bytes.Compare(s, d); c > 0 || (c == 0 && srcPort > dstPort) {
+			s, d = d, s
+			srcPort, dstPort = dstPort, srcPort
+		}
+	}
+
+	buf := make([]byte, 0, 40)
+	buf = binary.BigEndian.AppendUint16(buf, seed)
+	buf = append(buf, s...)
+	buf = append(buf, d...)
+	// the protocol is followed by one byte of padding
+	buf = append(buf, protocol, 0)
+
+	switch protocol {
+	case ProtocolICMP, ProtocolTCP, ProtocolUDP, ProtocolICMPv6, ProtocolSCTP:
+		buf = binary.BigEndian.AppendUint16(buf, srcPort)
+		buf = binary.BigEndian.AppendUint16(buf, dstPort)
+	}
+
+	sum := sha1.Sum(buf)
+	return "1:" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+// icmpPorts returns the ports of an ICMP flow and whether the flow is one-way.
+func icmpPorts(types map[uint16]uint16, typ, code uint16) (uint16, uint16, bool) {
+	if t, ok := types[typ]; ok {
+		return typ, t, false
+	}
+
+	return typ, code, true
+}
@@ -0,0 +1,156 @@
+package ip
+
+import (
+	"net/netip"
+	"testing"
+)
+
+var communityIDTests = []struct {
+	name     string
+	seed     uint16
+	src      string
+	dst      string
+	srcPort  uint16
+	dstPort  uint16
+	protocol uint8
+	expected string
+}{
+	{
+		"tcp",
+		0,
+		"128.232.110.120",
+		"66.35.250.204",
+		34855,
+		80,
+		ProtocolTCP,
+		"1:LQU9qZlK+B5F3KDmev6m5PMibrg=",
+	},
+	{
+		"tcp reversed",
+		0,
+		"66.35.250.204",
+		"128.232.110.120",
+		80,
+		34855,
+		ProtocolTCP,
+		"1:LQU9qZlK+B5F3KDmev6m5PMibrg=",
+	},
+	{
+		"tcp seed",
+		1,
+		"128.232.110.120",
+		"66.35.250.204",
+		34855,
+		80,
+		ProtocolTCP,
+		"1:3V71V58M3Ksw/yuFALMcW0LAHvc=",
+	},
+	{
+		"tcp IPv4-mapped IPv6",
+		0,
+		"::ffff:128.232.110.120",
+		"66.35.250.204",
+		34855,
+		80,
+		ProtocolTCP,
+		"1:LQU9qZlK+B5F3KDmev6m5PMibrg=",
+	},
+	{
+		"icmp echo request",
+		0,
+		"192.168.0.89",
+		"192.168.0.1",
+		8,
+		0,
+		ProtocolICMP,
+		"1:X0snYXpgwiv9TZtqg64sgzUn6Dk=",
+	},
+	{
+		"icmp echo reply",
+		0,
+		"192.168.0.1",
+		"192.168.0.89",
+		0,
+		0,
+		ProtocolICMP,
+		"1:X0snYXpgwiv9TZtqg64sgzUn6Dk=",
+	},
+	{
+		"icmpv6 neighbor solicitation",
+		0,
+		"fe80::200:86ff:fe05:80da",
+		"fe80::260:97ff:fe07:69ea",
+		135,
+		0,
+		ProtocolICMPv6,
+		"1:dGHyGvjMfljg6Bppwm3bg0LO8TY=",
+	},
+	{
+		"icmpv6 neighbor advertisement",
+		0,
+		"fe80::260:97ff:fe07:69ea",
+		"fe80::200:86ff:fe05:80da",
+		136,
+		0,
+		ProtocolICMPv6,
+		"1:dGHyGvjMfljg6Bppwm3bg0LO8TY=",
+	},
+}
+
+func TestCommunityID(t *testing.T) {
+	for _, test := range communityIDTests {
+		t.Run(test.name, func(t *testing.T) {
+			id, err := CommunityID(test.seed, netip.MustParseAddr(test.src), netip.MustParseAddr(test.dst), test.srcPort, test.dstPort, test.protocol)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, id)
+			}
+		})
+	}
+}
+
+func TestCommunityIDICMP(t *testing.T) {
+	src := netip.MustParseAddr("192.168.0.89")
+	dst := netip.MustParseAddr("192.168.0.1")
+
+	// echo request and echo reply are the same flow
+	req, err := CommunityID(0, src, dst, 8, 0, ProtocolICMP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := CommunityID(0, dst, src, 0, 0, ProtocolICMP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if req != rep {
+		t.Errorf("expected %s, got %s", req, rep)
+	}
+
+	// destination unreachable is one-way and is not reordered
+	a, _ := CommunityID(0, src, dst, 3, 1, ProtocolICMP)
+	b, _ := CommunityID(0, dst, src, 3, 1, ProtocolICMP)
+	if a == b {
+		t.Errorf("expected one-way flows to differ, got %s", a)
+	}
+
+	// ICMPv6 destination unreachable is also one-way
+	src6 := netip.MustParseAddr("3ffe:507:0:1:200:86ff:fe05:80da")
+	dst6 := netip.MustParseAddr("3ffe:507:0:1:260:97ff:fe07:69ea")
+
+	a, _ = CommunityID(0, src6, dst6, 1, 0, ProtocolICMPv6)
+	b, _ = CommunityID(0, dst6, src6, 1, 0, ProtocolICMPv6)
+	if a == b {
+		t.Errorf("expected one-way flows to differ, got %s", a)
+	}
+}
+
+func TestCommunityIDInvalid(t *testing.T) {
+	if _, err := CommunityID(0, netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("fe80::1"), 1, 2, ProtocolUDP); err == nil {
+		t.Error("expected error for mixed address families")
+	}
+}
@@ -17,6 +17,13 @@ func Bytes(b []byte) string {
 	// http.DetectContentType occasionally (rarely) generates false positive matches for application/vnd.ms-fontobject when the bytes are application/x-gzip
 	case bytes.HasPrefix(b, []byte("\x1f\x8b\x08")):
 		return "application/x-gzip"
+	// http.DetectContentType cannot detect these compression formats
+	case bytes.HasPrefix(b, []byte("\x28\xb5\x2f\xfd")):
+		return "application/zstd"
+	case bytes.HasPrefix(b, []byte("\x04\x22\x4d\x18")):
+		return "application/x-lz4"
+	case bytes.HasPrefix(b, []byte("\xff\x06\x00\x00sNaPpY")):
+		return "application/x-snappy-framed"
 	default:
 		return http.DetectContentType(b)
 	}
@@ -20,6 +20,21 @@ var mediaTests = []struct {
 		[]byte("\x1f\x8b\x08"),
 		"application/x-gzip",
 	},
+	{
+		"zstd",
+		[]byte("\x28\xb5\x2f\xfd"),
+		"application/zstd",
+	},
+	{
+		"lz4",
+		[]byte("\x04\x22\x4d\x18"),
+		"application/x-lz4",
+	},
+	{
+		"snappy",
+		[]byte("\xff\x06\x00\x00sNaPpY"),
+		"application/x-snappy-framed",
+	},
 }
 
 func TestBytes(t *testing.T) {
@@ -0,0 +1,372 @@
+//go:build !wasm
+
+package process
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/linkedin/goavro/v2"
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/base64"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/file"
+)
+
+// avro processes data by converting it to and from Apache Avro
+// (https://avro.apache.org/). Avro data is converted to and from standard
+// JSON, which means that union values are not wrapped in an object that
+// describes their type.
+//
+// When handling objects, Avro data is stored in the object as base64 encoded
+// strings.
+//
+// This processor supports the data and object handling patterns.
+type procAvro struct {
+	process
+	Options procAvroOptions `json:"options"`
+
+	codec *goavro.Codec
+}
+
+type procAvroOptions struct {
+	// Direction determines whether data is encoded or decoded.
+	//
+	// Must be one of:
+	//
+	// - to: encode an object to Avro
+	//
+	// - from: decode Avro to an object
+	Direction string `json:"direction"`
+	// Format is the Avro encoding format.
+	//
+	// Must be one of:
+	//
+	// - binary: datum without a header
+	//
+	// - single_object: datum with a header that contains the
+	// schema fingerprint (https://avro.apache.org/docs/1.11.1/specification/#single-object-encoding)
+	//
+	// - ocf: Object Container File; when encoding, objects are
+	// written as a single record and arrays are written as multiple
+	// records. When decoding, records are always returned as an
+	// array (https://avro.apache.org/docs/1.11.1/specification/#object-container-files).
+	Format string `json:"format"`
+	// Schema contains the location of the Avro schema. This can be either
+	// a path on local disk, an HTTP(S) URL, or an AWS S3 URL.
+	//
+	// This is optional when decoding Object Container Files, which contain
+	// the schema used to write the data; in all other cases it is required.
+	Schema string `json:"schema"`
+	// Compression is the compression codec used when encoding Object
+	// Container Files.
+	//
+	// Must be one of:
+	//
+	// - null
+	//
+	// - deflate
+	//
+	// - snappy
+	//
+	// This is optional and defaults to null (no compression).
+	Compression string `json:"compression"`
+}
+
+// Create a new Avro processor.
+func newProcAvro(ctx context.Context, cfg config.Config) (p procAvro, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procAvro{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procAvro{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procAvro{}, fmt.Errorf("process: avro: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	//  validate option.format
+	if !slices.Contains(
+		[]string{
+			"binary",
+			"single_object",
+			"ocf",
+		},
+		p.Options.Format) {
+		return procAvro{}, fmt.Errorf("process: avro: format %q: %v", p.Options.Format, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Compression == "" {
+		p.Options.Compression = goavro.CompressionNullLabel
+	}
+
+	//  validate option.compression
+	if !slices.Contains(
+		[]string{
+			goavro.CompressionNullLabel,
+			goavro.CompressionDeflateLabel,
+			goavro.CompressionSnappyLabel,
+		},
+		p.Options.Compression) {
+		return procAvro{}, fmt.Errorf("process: avro: compression %q: %v", p.Options.Compression, errors.ErrInvalidOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procAvro{}, fmt.Errorf("process: avro: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	// Object Container Files contain their own schema
+	if p.Options.Schema == "" {
+		if p.Options.Format == "ocf" && p.Options.Direction == "from" {
+			return p, nil
+		}
+
+		return procAvro{}, fmt.Errorf("process: avro: schema: %v", errors.ErrMissingRequiredOption)
+	}
+
+	path, err := file.Get(ctx, p.Options.Schema)
+	defer os.Remove(path)
+
+	if err != nil {
+		return procAvro{}, fmt.Errorf("process: avro: %v", err)
+	}
+
+	schema, err := os.ReadFile(path)
+	if err != nil {
+		return procAvro{}, fmt.Errorf("process: avro: %v", err)
+	}
+
+	p.codec, err = goavro.NewCodecForStandardJSONFull(string(schema))
+	if err != nil {
+		return procAvro{}, fmt.Errorf("process: avro: schema %s: %v", p.Options.Schema, err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procAvro) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procAvro) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procAvro) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procAvro) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key)
+
+		switch p.Options.Direction {
+		case "from":
+			b, err := base64.Decode([]byte(result.String()))
+			if err != nil {
+				return capsule, fmt.Errorf("process: avro: %v", err)
+			}
+
+			value, err := p.from(b)
+			if err != nil {
+				return capsule, fmt.Errorf("process: avro: %v", err)
+			}
+
+			if err := capsule.SetRaw(p.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: avro: %v", err)
+			}
+		case "to":
+			value, err := p.to([]byte(result.Raw))
+			if err != nil {
+				return capsule, fmt.Errorf("process: avro: %v", err)
+			}
+
+			if err := capsule.Set(p.SetKey, string(base64.Encode(value))); err != nil {
+				return capsule, fmt.Errorf("process: avro: %v", err)
+			}
+		default:
+			return capsule, fmt.Errorf("process: avro: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		var value []byte
+		var err error
+
+		switch p.Options.Direction {
+		case "from":
+			value, err = p.from(capsule.Data())
+		case "to":
+			value, err = p.to(capsule.Data())
+		default:
+			return capsule, fmt.Errorf("process: avro: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		if err != nil {
+			return capsule, fmt.Errorf("process: avro: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: avro: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// from decodes Avro data into JSON.
+func (p procAvro) from(data []byte) ([]byte, error) {
+	switch p.Options.Format {
+	case "binary":
+		native, _, err := p.codec.NativeFromBinary(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return avroTextual(p.codec, native)
+	case "single_object":
+		native, _, err := p.codec.NativeFromSingle(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return avroTextual(p.codec, native)
+	case "ocf":
+		ocf, err := goavro.NewOCFReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		// the schema from the file is converted to a codec that
+		// supports standard JSON
+		codec, err := goavro.NewCodecForStandardJSONFull(ocf.Codec().Schema())
+		if err != nil {
+			return nil, err
+		}
+
+		records := []gojson.RawMessage{}
+		for ocf.Scan() {
+			native, err := ocf.Read()
+			if err != nil {
+				return nil, err
+			}
+
+			b, err := avroTextual(codec, native)
+			if err != nil {
+				return nil, err
+			}
+
+			records = append(records, b)
+		}
+
+		if err := ocf.Err(); err != nil {
+			return nil, err
+		}
+
+		return gojson.Marshal(records)
+	default:
+		return nil, fmt.Errorf("format %s: %v", p.Options.Format, errors.ErrInvalidOption)
+	}
+}
+
+// avroTextual converts Avro native data into JSON. Records are not
+// converted in a consistent order, so the output is re-encoded to produce
+// objects with sorted keys.
+func avroTextual(codec *goavro.Codec, native interface{}) ([]byte, error) {
+	b, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := gojson.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return gojson.Marshal(v)
+}
+
+// to encodes JSON into Avro data.
+func (p procAvro) to(data []byte) ([]byte, error) {
+	switch p.Options.Format {
+	case "binary":
+		native, _, err := p.codec.NativeFromTextual(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.codec.BinaryFromNative(nil, native)
+	case "single_object":
+		native, _, err := p.codec.NativeFromTextual(data)
+		if err != nil {
+			return nil, err
+		}
+
+		return p.codec.SingleFromNative(nil, native)
+	case "ocf":
+		var records []gojson.RawMessage
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`[`)) {
+			if err := gojson.Unmarshal(data, &records); err != nil {
+				return nil, err
+			}
+		} else {
+			records = append(records, data)
+		}
+
+		var buf bytes.Buffer
+		ocf, err := goavro.NewOCFWriter(goavro.OCFConfig{
+			W:               &buf,
+			Codec:           p.codec,
+			CompressionName: p.Options.Compression,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		natives := make([]interface{}, 0, len(records))
+		for _, r := range records {
+			native, _, err := p.codec.NativeFromTextual(r)
+			if err != nil {
+				return nil, err
+			}
+
+			natives = append(natives, native)
+		}
+
+		if err := ocf.Append(natives); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("format %s: %v", p.Options.Format, errors.ErrInvalidOption)
+	}
+}
@@ -0,0 +1,220 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procAvro{}
+	_ Batcher = procAvro{}
+)
+
+const avroTestSchema = `{"type":"record","name":"Event","namespace":"substation.test","fields":[{"name":"name","type":"string"},{"name":"count","type":"long"},{"name":"tag","type":["null","string"],"default":null}]}`
+
+var avroTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON from binary",
+		config.Config{
+			Type: "avro",
+			Settings: map[string]interface{}{
+				"key":     "avro",
+				"set_key": "avro",
+				"options": map[string]interface{}{
+					"direction": "from",
+					"format":    "binary",
+				},
+			},
+		},
+		[]byte(`{"avro":"BmZvbwYCBmJhcg=="}`),
+		[]byte(`{"avro":{"count":3,"name":"foo","tag":"bar"}}`),
+		nil,
+	},
+	{
+		"JSON to binary",
+		config.Config{
+			Type: "avro",
+			Settings: map[string]interface{}{
+				"key":     "avro",
+				"set_key": "avro",
+				"options": map[string]interface{}{
+					"direction": "to",
+					"format":    "binary",
+				},
+			},
+		},
+		[]byte(`{"avro":{"name":"foo","count":3,"tag":"bar"}}`),
+		[]byte(`{"avro":"BmZvbwYCBmJhcg=="}`),
+		nil,
+	},
+	{
+		"data from single_object",
+		config.Config{
+			Type: "avro",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"format":    "single_object",
+				},
+			},
+		},
+		[]byte("\xc3\x01^s\xac\x12>Of\xa7\x06foo\x06\x02\x06bar"),
+		[]byte(`{"count":3,"name":"foo","tag":"bar"}`),
+		nil,
+	},
+	{
+		"data to single_object",
+		config.Config{
+			Type: "avro",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "to",
+					"format":    "single_object",
+				},
+			},
+		},
+		[]byte(`{"name":"foo","count":3,"tag":"bar"}`),
+		[]byte("\xc3\x01^s\xac\x12>Of\xa7\x06foo\x06\x02\x06bar"),
+		nil,
+	},
+}
+
+// avroTestFile writes the test schema to a temporary file and returns the
+// name of the file.
+func avroTestFile(t testing.TB) (string, error) {
+	f, err := os.CreateTemp(t.TempDir(), "schema")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(avroTestSchema); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+func TestAvro(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	schema, err := avroTestFile(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range avroTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			test.cfg.Settings["options"].(map[string]interface{})["schema"] = schema
+			proc, err := newProcAvro(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+// Object Container Files contain a random sync marker, so they are tested by
+// encoding and decoding the same data.
+func TestAvroOCF(t *testing.T) {
+	ctx := context.TODO()
+
+	schema, err := avroTestFile(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	to, err := newProcAvro(ctx, config.Config{
+		Type: "avro",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"direction":   "to",
+				"format":      "ocf",
+				"schema":      schema,
+				"compression": "deflate",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the schema is read from the file
+	from, err := newProcAvro(ctx, config.Config{
+		Type: "avro",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"direction": "from",
+				"format":    "ocf",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := []byte(`[{"name":"foo","count":1,"tag":null},{"name":"bar","count":2,"tag":"baz"}]`)
+	expected := []byte(`[{"count":1,"name":"foo","tag":null},{"count":2,"name":"bar","tag":"baz"}]`)
+
+	result, err := ApplyBytes(ctx, test, to, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result, expected) {
+		t.Errorf("expected %s, got %s", expected, result)
+	}
+}
+
+func benchmarkAvro(b *testing.B, applier procAvro, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkAvro(b *testing.B) {
+	capsule := config.NewCapsule()
+
+	schema, err := avroTestFile(b)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, test := range avroTests {
+		test.cfg.Settings["options"].(map[string]interface{})["schema"] = schema
+		proc, err := newProcAvro(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkAvro(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procAvro struct {
+	process
+	Options procAvroOptions `json:"options"`
+}
+
+type procAvroOptions struct{}
+
+func newProcAvro(ctx context.Context, cfg config.Config) (p procAvro, err error) {
+	return procAvro{}, fmt.Errorf("process: avro: %v", syscall.ENOSYS)
+}
+
+func (p procAvro) String() string {
+	return toString(p)
+}
+
+func (p procAvro) Close(ctx context.Context) error {
+	return fmt.Errorf("close: avro: %v", syscall.ENOSYS)
+}
+
+func (p procAvro) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procAvro) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: avro: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,274 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
+	"github.com/brexhq/substation/internal/metrics"
+)
+
+// cache processes data by wrapping a processor and caching its output in a
+// key-value (KV) store. The value from Key is used to look up the output in
+// the store: if the output is found (a hit), then it is put into SetKey
+// without applying the processor, otherwise (a miss) the processor is
+// applied and its output is put into the store. This is useful for
+// processors that make expensive or slow requests to external services
+// (e.g., aws_lambda, dns, http).
+//
+// The number of hits and misses in each batch are reported as the CacheHits
+// and CacheMisses metrics. Metrics are not reported if the processor is
+// applied to individual capsules (e.g., by the pipeline processor).
+//
+// This processor supports the data and object handling patterns. If Key is
+// not set, then the data is used to look up the output. If SetKey is not set,
+// then the set_key of the wrapped processor is used, and if that is not set,
+// then the processed data is cached (Key cannot be set when caching the
+// processed data). If Key is set but does not exist in
+// the data, then the processor is applied without using the cache.
+type procCache struct {
+	process
+	Options procCacheOptions `json:"options"`
+
+	setKey  string
+	applier Applier
+	kvStore kv.Storer
+}
+
+type procCacheOptions struct {
+	// Processor is the processor that is applied on a cache miss.
+	Processor config.Config `json:"processor"`
+	// Prefix is prepended to the value from Key and is intended to simplify
+	// data management within a KV store.
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+	// OffsetTTL is an offset (in seconds) used to determine the time-to-live
+	// (TTL) of the output put into the KV store. TTL is calculated based on the
+	// current time plus the offset.
+	//
+	// This is optional and defaults to using no TTL when setting values into
+	// the store.
+	OffsetTTL int `json:"offset_ttl"`
+	// KVOptions determine the type of KV store used by the processor. Refer to
+	// internal/kv for more information.
+	KVOptions config.Config `json:"kv_options"`
+}
+
+// Create a new cache processor.
+func newProcCache(ctx context.Context, cfg config.Config) (p procCache, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procCache{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procCache{}, err
+	}
+
+	// error early if required options are missing
+	if p.Options.Processor.Type == "" || p.Options.KVOptions.Type == "" {
+		return procCache{}, fmt.Errorf("process: cache: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	p.applier, err = NewApplier(ctx, p.Options.Processor)
+	if err != nil {
+		return procCache{}, fmt.Errorf("process: cache: %v", err)
+	}
+
+	p.setKey = p.SetKey
+	if p.setKey == "" {
+		if setKey, ok := p.Options.Processor.Settings["set_key"].(string); ok {
+			p.setKey = setKey
+		}
+	}
+
+	// if the data is cached, then it must be looked up by the data,
+	// otherwise events that share a key would share the same data
+	if p.Key != "" && p.setKey == "" {
+		return procCache{}, fmt.Errorf("process: cache: key %s set_key %s: %v", p.Key, p.setKey, errInvalidDataPattern)
+	}
+
+	p.kvStore, err = kv.Get(p.Options.KVOptions)
+	if err != nil {
+		return procCache{}, fmt.Errorf("process: cache: %v", err)
+	}
+
+	// lazy load the KV store
+	if !p.kvStore.IsEnabled() {
+		if err := p.kvStore.Setup(ctx); err != nil {
+			return procCache{}, fmt.Errorf("process: cache: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procCache) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procCache) Close(ctx context.Context) error {
+	if err := p.applier.Close(ctx); err != nil {
+		return fmt.Errorf("close: cache: %v", err)
+	}
+
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if p.kvStore.IsEnabled() {
+		if err := p.kvStore.Close(); err != nil {
+			return fmt.Errorf("close: cache: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procCache) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	var hits, misses int
+
+	newCapsules := newBatch(&capsules)
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: cache: %v", err)
+		}
+
+		if !ok {
+			newCapsules = append(newCapsules, capsule)
+			continue
+		}
+
+		newCapsule, hit, err := p.cache(ctx, capsule)
+		if err != nil {
+			return nil, err
+		}
+
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+
+		newCapsules = append(newCapsules, newCapsule)
+	}
+
+	p.metrics(ctx, hits, misses)
+	return newCapsules, nil
+}
+
+// Apply processes a capsule with the processor.
+func (p procCache) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	capsule, _, err := p.cache(ctx, capsule)
+	return capsule, err
+}
+
+// cache returns the output from the KV store or the processor and whether
+// the output was found in the store.
+func (p procCache) cache(ctx context.Context, capsule config.Capsule) (config.Capsule, bool, error) {
+	key := string(capsule.Data())
+	if p.Key != "" {
+		res := capsule.Get(p.Key)
+
+		// capsules without a key bypass the cache, otherwise they would
+		// share a single entry
+		if !res.Exists() {
+			capsule, err := p.applier.Apply(ctx, capsule)
+			if err != nil {
+				return capsule, false, fmt.Errorf("process: cache: %v", err)
+			}
+
+			return capsule, false, nil
+		}
+
+		key = res.String()
+	}
+
+	if p.Options.Prefix != "" {
+		key = fmt.Sprint(p.Options.Prefix, ":", key)
+	}
+
+	val, err := p.kvStore.Get(ctx, key)
+	if err != nil {
+		return capsule, false, fmt.Errorf("process: cache: %v", err)
+	}
+
+	// hit
+	if val != nil {
+		// JSON processing
+		if p.setKey != "" {
+			if err := capsule.SetRaw(p.setKey, fmt.Sprint(val)); err != nil {
+				return capsule, false, fmt.Errorf("process: cache: %v", err)
+			}
+
+			return capsule, true, nil
+		}
+
+		// data processing
+		capsule.SetData([]byte(fmt.Sprint(val)))
+		return capsule, true, nil
+	}
+
+	// miss
+	capsule, err = p.applier.Apply(ctx, capsule)
+	if err != nil {
+		return capsule, false, fmt.Errorf("process: cache: %v", err)
+	}
+
+	// outputs are stored as JSON so that their type is preserved
+	output := string(capsule.Data())
+	if p.setKey != "" {
+		res := capsule.Get(p.setKey)
+
+		// missing outputs are not cached so that they are retried
+		if !res.Exists() {
+			return capsule, false, nil
+		}
+
+		output = res.Raw
+	}
+
+	if p.Options.OffsetTTL == 0 {
+		if err := p.kvStore.Set(ctx, key, output); err != nil {
+			return capsule, false, fmt.Errorf("process: cache: %v", err)
+		}
+	} else {
+		ttl := time.Now().Add(time.Duration(p.Options.OffsetTTL) * time.Second).Unix()
+		if err := p.kvStore.SetWithTTL(ctx, key, output, ttl); err != nil {
+			return capsule, false, fmt.Errorf("process: cache: %v", err)
+		}
+	}
+
+	return capsule, false, nil
+}
+
+// metrics reports the number of cache hits and misses.
+func (p procCache) metrics(ctx context.Context, hits, misses int) {
+	attr := map[string]string{
+		"ProcessorType": p.Options.Processor.Type,
+	}
+
+	_ = metrics.Generate(ctx, metrics.Data{
+		Attributes: attr,
+		Name:       "CacheHits",
+		Value:      hits,
+	})
+
+	_ = metrics.Generate(ctx, metrics.Data{
+		Attributes: attr,
+		Name:       "CacheMisses",
+		Value:      misses,
+	})
+}
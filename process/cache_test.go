@@ -0,0 +1,316 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procCache{}
+	_ Batcher = procCache{}
+)
+
+var cacheTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+}{
+	{
+		"JSON",
+		config.Config{
+			Type: "cache",
+			Settings: map[string]interface{}{
+				"key": "a",
+				"options": map[string]interface{}{
+					"processor": map[string]interface{}{
+						"type": "copy",
+						"settings": map[string]interface{}{
+							"key":     "b",
+							"set_key": "c",
+						},
+					},
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 301,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"a":"x","b":1}`),
+			[]byte(`{"a":"x","b":2}`),
+			[]byte(`{"a":"y","b":3}`),
+		},
+		[][]byte{
+			[]byte(`{"a":"x","b":1,"c":1}`),
+			[]byte(`{"a":"x","b":2,"c":1}`),
+			[]byte(`{"a":"y","b":3,"c":3}`),
+		},
+	},
+	{
+		"data",
+		config.Config{
+			Type: "cache",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"prefix": "foo",
+					"processor": map[string]interface{}{
+						"type": "case",
+						"settings": map[string]interface{}{
+							"options": map[string]interface{}{
+								"type": "upper",
+							},
+						},
+					},
+					"offset_ttl": 60,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 302,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`foo`),
+			[]byte(`foo`),
+			[]byte(`bar`),
+		},
+		[][]byte{
+			[]byte(`FOO`),
+			[]byte(`FOO`),
+			[]byte(`BAR`),
+		},
+	},
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range cacheTests {
+		t.Run(test.name, func(t *testing.T) {
+			proc, err := newProcCache(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer proc.Close(ctx)
+
+			for i, data := range test.test {
+				capsule := config.NewCapsule()
+				capsule.SetData(data)
+
+				result, err := proc.Apply(ctx, capsule)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(result.Data(), test.expected[i]) {
+					t.Errorf("expected %s, got %s", test.expected[i], result.Data())
+				}
+			}
+		})
+	}
+}
+
+func TestCacheMissingKey(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcCache(ctx, config.Config{
+		Type: "cache",
+		Settings: map[string]interface{}{
+			"key": "a",
+			"options": map[string]interface{}{
+				"processor": map[string]interface{}{
+					"type": "copy",
+					"settings": map[string]interface{}{
+						"key":     "b",
+						"set_key": "c",
+					},
+				},
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 304,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	for _, test := range []struct {
+		data     []byte
+		expected []byte
+	}{
+		{[]byte(`{"b":1}`), []byte(`{"b":1,"c":1}`)},
+		{[]byte(`{"b":2}`), []byte(`{"b":2,"c":2}`)},
+	} {
+		capsule := config.NewCapsule()
+		capsule.SetData(test.data)
+
+		result, err := proc.Apply(ctx, capsule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(result.Data(), test.expected) {
+			t.Errorf("expected %s, got %s", test.expected, result.Data())
+		}
+	}
+}
+
+func TestCacheData(t *testing.T) {
+	ctx := context.TODO()
+
+	processor := map[string]interface{}{
+		"type": "case",
+		"settings": map[string]interface{}{
+			"options": map[string]interface{}{
+				"type": "upper",
+			},
+		},
+	}
+
+	// caching the processed data cannot be looked up by a key
+	if _, err := newProcCache(ctx, config.Config{
+		Type: "cache",
+		Settings: map[string]interface{}{
+			"key": "id",
+			"options": map[string]interface{}{
+				"processor": processor,
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 305,
+					},
+				},
+			},
+		},
+	}); err == nil {
+		t.Error("expected error")
+	}
+
+	proc, err := newProcCache(ctx, config.Config{
+		Type: "cache",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"processor": processor,
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 305,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	for _, test := range []struct {
+		data     []byte
+		expected []byte
+	}{
+		{[]byte(`{"id":"a","msg":"first"}`), []byte(`{"ID":"A","MSG":"FIRST"}`)},
+		{[]byte(`{"id":"a","msg":"second"}`), []byte(`{"ID":"A","MSG":"SECOND"}`)},
+	} {
+		capsule := config.NewCapsule()
+		capsule.SetData(test.data)
+
+		result, err := proc.Apply(ctx, capsule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(result.Data(), test.expected) {
+			t.Errorf("expected %s, got %s", test.expected, result.Data())
+		}
+	}
+}
+
+func TestCacheBatch(t *testing.T) {
+	ctx := context.TODO()
+	test := cacheTests[0]
+
+	cfg := config.Config{
+		Type: "cache",
+		Settings: map[string]interface{}{
+			"key": "a",
+			"options": map[string]interface{}{
+				"processor": map[string]interface{}{
+					"type": "copy",
+					"settings": map[string]interface{}{
+						"key":     "b",
+						"set_key": "c",
+					},
+				},
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 303,
+					},
+				},
+			},
+		},
+	}
+
+	proc, err := newProcCache(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	var capsules []config.Capsule
+	for _, data := range test.test {
+		capsule := config.NewCapsule()
+		capsule.SetData(data)
+		capsules = append(capsules, capsule)
+	}
+
+	result, err := proc.Batch(ctx, capsules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, capsule := range result {
+		if !bytes.Equal(capsule.Data(), test.expected[i]) {
+			t.Errorf("expected %s, got %s", test.expected[i], capsule.Data())
+		}
+	}
+}
+
+func benchmarkCache(b *testing.B, applier procCache, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkCache(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range cacheTests {
+		proc, err := newProcCache(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test[0])
+				benchmarkCache(b, proc, capsule)
+			},
+		)
+	}
+}
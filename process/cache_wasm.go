@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procCache struct {
+	process
+	Options procCacheOptions `json:"options"`
+}
+
+type procCacheOptions struct{}
+
+func newProcCache(ctx context.Context, cfg config.Config) (p procCache, err error) {
+	return procCache{}, fmt.Errorf("process: cache: %v", syscall.ENOSYS)
+}
+
+func (p procCache) String() string {
+	return toString(p)
+}
+
+func (p procCache) Close(ctx context.Context) error {
+	return fmt.Errorf("close: cache: %v", syscall.ENOSYS)
+}
+
+func (p procCache) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procCache) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: cache: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,174 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/ip"
+	"github.com/brexhq/substation/internal/json"
+)
+
+// communityIDProtocols maps protocol names to IANA protocol numbers.
+var communityIDProtocols = map[string]uint8{
+	"icmp":      ip.ProtocolICMP,
+	"tcp":       ip.ProtocolTCP,
+	"udp":       ip.ProtocolUDP,
+	"icmpv6":    ip.ProtocolICMPv6,
+	"icmp6":     ip.ProtocolICMPv6,
+	"ipv6-icmp": ip.ProtocolICMPv6,
+	"sctp":      ip.ProtocolSCTP,
+}
+
+// communityID processes data by calculating the Community ID flow hash
+// (https://github.com/corelight/community-id-spec) of a network flow. The
+// Community ID is the same for both directions of a flow and can be used to
+// correlate flows across data sources (e.g., Zeek, Suricata, VPC Flow Logs).
+// If the source IP, destination IP, or protocol are missing, then the data is
+// not modified.
+//
+// This processor supports the object handling pattern.
+type procCommunityID struct {
+	process
+	Options procCommunityIDOptions `json:"options"`
+}
+
+type procCommunityIDOptions struct {
+	// SourceIPKey retrieves the source IP address from an object.
+	SourceIPKey string `json:"source_ip_key"`
+	// DestinationIPKey retrieves the destination IP address from an object.
+	DestinationIPKey string `json:"destination_ip_key"`
+	// SourcePortKey retrieves the source port from an object. For ICMP
+	// flows, this is the message type.
+	//
+	// This is optional for protocols that do not use ports.
+	SourcePortKey string `json:"source_port_key"`
+	// DestinationPortKey retrieves the destination port from an object. For
+	// ICMP flows, this is the message code.
+	//
+	// This is optional for protocols that do not use ports.
+	DestinationPortKey string `json:"destination_port_key"`
+	// ProtocolKey retrieves the protocol from an object. The protocol can be
+	// an IANA protocol number (e.g., 6) or one of these names: icmp, tcp,
+	// udp, icmpv6 (or icmp6, ipv6-icmp), sctp.
+	ProtocolKey string `json:"protocol_key"`
+	// Seed is added to the hash to separate flows from different networks.
+	// Must be between 0 and 65535.
+	//
+	// This is optional and defaults to 0.
+	Seed int `json:"seed"`
+}
+
+// String returns the processor settings as an object.
+func (p procCommunityID) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procCommunityID) Close(context.Context) error {
+	return nil
+}
+
+// Create a new community ID processor.
+func newProcCommunityID(ctx context.Context, cfg config.Config) (p procCommunityID, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procCommunityID{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procCommunityID{}, err
+	}
+
+	// only supports JSON, fail if there is no set key
+	if p.SetKey == "" {
+		return procCommunityID{}, fmt.Errorf("process: community_id: set_key %s: %v", p.SetKey, errInvalidDataPattern)
+	}
+
+	// error early if required options are missing
+	if p.Options.SourceIPKey == "" || p.Options.DestinationIPKey == "" || p.Options.ProtocolKey == "" {
+		return procCommunityID{}, fmt.Errorf("process: community_id: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.Seed < 0 || p.Options.Seed > 65535 {
+		return procCommunityID{}, fmt.Errorf("process: community_id: seed %d: %v", p.Options.Seed, errors.ErrInvalidOption)
+	}
+
+	return p, nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procCommunityID) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procCommunityID) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	src := capsule.Get(p.Options.SourceIPKey)
+	dst := capsule.Get(p.Options.DestinationIPKey)
+	proto := capsule.Get(p.Options.ProtocolKey)
+	if !src.Exists() || !dst.Exists() || !proto.Exists() {
+		return capsule, nil
+	}
+
+	srcIP, err := netip.ParseAddr(src.String())
+	if err != nil {
+		return capsule, fmt.Errorf("process: community_id: %v", err)
+	}
+
+	dstIP, err := netip.ParseAddr(dst.String())
+	if err != nil {
+		return capsule, fmt.Errorf("process: community_id: %v", err)
+	}
+
+	protocol, err := communityIDProtocol(proto)
+	if err != nil {
+		return capsule, fmt.Errorf("process: community_id: %v", err)
+	}
+
+	var ports [2]uint16
+	for i, key := range []string{p.Options.SourcePortKey, p.Options.DestinationPortKey} {
+		if key == "" {
+			continue
+		}
+
+		port, err := strconv.ParseUint(capsule.Get(key).String(), 10, 16)
+		if err != nil && capsule.Get(key).Exists() {
+			return capsule, fmt.Errorf("process: community_id: port %s: %v", capsule.Get(key).String(), err)
+		}
+
+		ports[i] = uint16(port)
+	}
+
+	id, err := ip.CommunityID(uint16(p.Options.Seed), srcIP, dstIP, ports[0], ports[1], protocol)
+	if err != nil {
+		return capsule, fmt.Errorf("process: community_id: %v", err)
+	}
+
+	if err := capsule.Set(p.SetKey, id); err != nil {
+		return capsule, fmt.Errorf("process: community_id: %v", err)
+	}
+
+	return capsule, nil
+}
+
+// communityIDProtocol returns the IANA protocol number of a protocol name or
+// number.
+func communityIDProtocol(result json.Result) (uint8, error) {
+	if n, ok := communityIDProtocols[strings.ToLower(result.String())]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.ParseUint(result.String(), 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("protocol %s: %v", result.String(), err)
+	}
+
+	return uint8(n), nil
+}
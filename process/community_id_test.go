@@ -0,0 +1,185 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procCommunityID{}
+	_ Batcher = procCommunityID{}
+)
+
+var communityIDTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"zeek",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":        "id\\.orig_h",
+					"source_port_key":      "id\\.orig_p",
+					"destination_ip_key":   "id\\.resp_h",
+					"destination_port_key": "id\\.resp_p",
+					"protocol_key":         "proto",
+				},
+			},
+		},
+		[]byte(`{"id.orig_h":"128.232.110.120","id.orig_p":34855,"id.resp_h":"66.35.250.204","id.resp_p":80,"proto":"tcp"}`),
+		[]byte(`{"id.orig_h":"128.232.110.120","id.orig_p":34855,"id.resp_h":"66.35.250.204","id.resp_p":80,"proto":"tcp","community_id":"1:LQU9qZlK+B5F3KDmev6m5PMibrg="}`),
+		nil,
+	},
+	{
+		"vpc flow logs",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":        "srcaddr",
+					"source_port_key":      "srcport",
+					"destination_ip_key":   "dstaddr",
+					"destination_port_key": "dstport",
+					"protocol_key":         "protocol",
+				},
+			},
+		},
+		[]byte(`{"srcaddr":"66.35.250.204","srcport":"80","dstaddr":"128.232.110.120","dstport":"34855","protocol":"6"}`),
+		[]byte(`{"srcaddr":"66.35.250.204","srcport":"80","dstaddr":"128.232.110.120","dstport":"34855","protocol":"6","community_id":"1:LQU9qZlK+B5F3KDmev6m5PMibrg="}`),
+		nil,
+	},
+	{
+		"seed",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":        "src_ip",
+					"source_port_key":      "src_port",
+					"destination_ip_key":   "dest_ip",
+					"destination_port_key": "dest_port",
+					"protocol_key":         "proto",
+					"seed":                 1,
+				},
+			},
+		},
+		[]byte(`{"src_ip":"128.232.110.120","src_port":34855,"dest_ip":"66.35.250.204","dest_port":80,"proto":"TCP"}`),
+		[]byte(`{"src_ip":"128.232.110.120","src_port":34855,"dest_ip":"66.35.250.204","dest_port":80,"proto":"TCP","community_id":"1:3V71V58M3Ksw/yuFALMcW0LAHvc="}`),
+		nil,
+	},
+	{
+		"icmp",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":        "id\\.orig_h",
+					"source_port_key":      "id\\.orig_p",
+					"destination_ip_key":   "id\\.resp_h",
+					"destination_port_key": "id\\.resp_p",
+					"protocol_key":         "proto",
+				},
+			},
+		},
+		[]byte(`{"id.orig_h":"192.168.0.89","id.orig_p":8,"id.resp_h":"192.168.0.1","id.resp_p":0,"proto":"icmp"}`),
+		[]byte(`{"id.orig_h":"192.168.0.89","id.orig_p":8,"id.resp_h":"192.168.0.1","id.resp_p":0,"proto":"icmp","community_id":"1:X0snYXpgwiv9TZtqg64sgzUn6Dk="}`),
+		nil,
+	},
+	{
+		"icmpv6",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":        "id\\.orig_h",
+					"source_port_key":      "id\\.orig_p",
+					"destination_ip_key":   "id\\.resp_h",
+					"destination_port_key": "id\\.resp_p",
+					"protocol_key":         "proto",
+				},
+			},
+		},
+		[]byte(`{"id.orig_h":"fe80::200:86ff:fe05:80da","id.orig_p":135,"id.resp_h":"fe80::260:97ff:fe07:69ea","id.resp_p":0,"proto":"icmp6"}`),
+		[]byte(`{"id.orig_h":"fe80::200:86ff:fe05:80da","id.orig_p":135,"id.resp_h":"fe80::260:97ff:fe07:69ea","id.resp_p":0,"proto":"icmp6","community_id":"1:dGHyGvjMfljg6Bppwm3bg0LO8TY="}`),
+		nil,
+	},
+	{
+		"missing",
+		config.Config{
+			Type: "community_id",
+			Settings: map[string]interface{}{
+				"set_key": "community_id",
+				"options": map[string]interface{}{
+					"source_ip_key":      "src_ip",
+					"destination_ip_key": "dest_ip",
+					"protocol_key":       "proto",
+				},
+			},
+		},
+		[]byte(`{"src_ip":"128.232.110.120"}`),
+		[]byte(`{"src_ip":"128.232.110.120"}`),
+		nil,
+	},
+}
+
+func TestCommunityID(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range communityIDTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcCommunityID(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkCommunityID(b *testing.B, applier procCommunityID, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkCommunityID(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range communityIDTests {
+		proc, err := newProcCommunityID(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkCommunityID(b, proc, capsule)
+			},
+		)
+	}
+}
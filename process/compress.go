@@ -0,0 +1,359 @@
+package process
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/media"
+)
+
+// compress processes data by compressing or decompressing it.
+//
+// This processor supports the data handling pattern.
+type procCompress struct {
+	process
+	Options procCompressOptions `json:"options"`
+
+	// zstd encoders and decoders are expensive to create, so they are
+	// created once and reused.
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+}
+
+type procCompressOptions struct {
+	// Direction determines whether data is compressed or decompressed.
+	//
+	// Must be one of:
+	//
+	// - to: compress data
+	//
+	// - from: decompress data
+	Direction string `json:"direction"`
+	// Type is the compression format.
+	//
+	// Must be one of:
+	//
+	// - gzip (https://en.wikipedia.org/wiki/Gzip)
+	//
+	// - zlib (https://en.wikipedia.org/wiki/Zlib)
+	//
+	// - deflate (https://en.wikipedia.org/wiki/Deflate)
+	//
+	// - zstd (https://en.wikipedia.org/wiki/Zstd)
+	//
+	// - snappy (https://en.wikipedia.org/wiki/Snappy_(compression)): data is
+	// compressed using the framing format and decompressed from either the
+	// framing or block format
+	//
+	// - lz4 (https://en.wikipedia.org/wiki/LZ4_(compression_algorithm)): data
+	// is compressed using the frame format
+	//
+	// - bzip2 (https://en.wikipedia.org/wiki/Bzip2): only supports decompression
+	//
+	// - brotli (https://en.wikipedia.org/wiki/Brotli)
+	//
+	// - auto: the format is detected from the data and only supports
+	// decompression. Detection is supported for bzip2, gzip, lz4, snappy,
+	// and zstd; data in other formats is not modified.
+	Type string `json:"type"`
+	// Level is the compression level used when compressing data. The range
+	// of levels depends on the format:
+	//
+	// - gzip, zlib, deflate: -2 to 9
+	//
+	// - zstd: 1 to 22
+	//
+	// - lz4: 1 to 9
+	//
+	// - brotli: 0 to 11
+	//
+	// This is optional and defaults to the default level of the format.
+	// Levels are not supported by snappy.
+	Level *int `json:"level"`
+}
+
+// Create a new compress processor.
+func newProcCompress(ctx context.Context, cfg config.Config) (p procCompress, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procCompress{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procCompress{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procCompress{}, fmt.Errorf("process: compress: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"gzip",
+			"zlib",
+			"deflate",
+			"zstd",
+			"snappy",
+			"lz4",
+			"bzip2",
+			"brotli",
+			"auto",
+		},
+		p.Options.Type) {
+		return procCompress{}, fmt.Errorf("process: compress: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	// some types only support decompression
+	if p.Options.Direction == "to" && (p.Options.Type == "bzip2" || p.Options.Type == "auto") {
+		return procCompress{}, fmt.Errorf("process: compress: type %q direction %q: %v", p.Options.Type, p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	var min, max int
+	switch p.Options.Type {
+	case "gzip", "zlib", "deflate":
+		min, max = flate.HuffmanOnly, flate.BestCompression
+	case "zstd":
+		min, max = 1, 22
+	case "lz4":
+		min, max = 1, 9
+	case "brotli":
+		min, max = brotli.BestSpeed, brotli.BestCompression
+	}
+
+	if p.Options.Level != nil && (*p.Options.Level < min || *p.Options.Level > max) {
+		return procCompress{}, fmt.Errorf("process: compress: level %d: %v", *p.Options.Level, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Direction == "to" && p.Options.Type == "zstd" {
+		level := zstd.SpeedDefault
+		if p.Options.Level != nil {
+			level = zstd.EncoderLevelFromZstd(*p.Options.Level)
+		}
+
+		p.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(level))
+		if err != nil {
+			return procCompress{}, fmt.Errorf("process: compress: %v", err)
+		}
+	}
+
+	if p.Options.Direction == "from" && (p.Options.Type == "zstd" || p.Options.Type == "auto") {
+		p.zstdDecoder, err = zstd.NewReader(nil)
+		if err != nil {
+			return procCompress{}, fmt.Errorf("process: compress: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procCompress) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procCompress) Close(context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if p.zstdEncoder != nil {
+		if err := p.zstdEncoder.Close(); err != nil {
+			return fmt.Errorf("close: compress: %v", err)
+		}
+	}
+
+	if p.zstdDecoder != nil {
+		p.zstdDecoder.Close()
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procCompress) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procCompress) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	var value []byte
+	switch p.Options.Direction {
+	case "from":
+		from, err := p.from(capsule.Data())
+		if err != nil {
+			return capsule, fmt.Errorf("process: compress: %v", err)
+		}
+
+		value = from
+	case "to":
+		to, err := p.to(capsule.Data())
+		if err != nil {
+			return capsule, fmt.Errorf("process: compress: %v", err)
+		}
+
+		value = to
+	default:
+		return capsule, fmt.Errorf("process: compress: direction %s: %v", p.Options.Direction, errInvalidDirection)
+	}
+
+	capsule.SetData(value)
+	return capsule, nil
+}
+
+func (p procCompress) from(data []byte) ([]byte, error) {
+	t := p.Options.Type
+	if t == "auto" {
+		switch media.Bytes(data) {
+		case "application/x-bzip2":
+			t = "bzip2"
+		case "application/x-gzip":
+			t = "gzip"
+		case "application/x-lz4":
+			t = "lz4"
+		case "application/x-snappy-framed":
+			t = "snappy"
+		case "application/zstd":
+			t = "zstd"
+		default:
+			return data, nil
+		}
+	}
+
+	var r io.Reader
+	switch t {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		r = gz
+	case "zlib":
+		z, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+
+		r = z
+	case "deflate":
+		r = flate.NewReader(bytes.NewReader(data))
+	case "zstd":
+		return p.zstdDecoder.DecodeAll(data, nil)
+	case "snappy":
+		if media.Bytes(data) != "application/x-snappy-framed" {
+			return snappy.Decode(nil, data)
+		}
+
+		r = snappy.NewReader(bytes.NewReader(data))
+	case "lz4":
+		r = lz4.NewReader(bytes.NewReader(data))
+	case "bzip2":
+		r = bzip2.NewReader(bytes.NewReader(data))
+	case "brotli":
+		r = brotli.NewReader(bytes.NewReader(data))
+	}
+
+	return io.ReadAll(r)
+}
+
+func (p procCompress) to(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+
+	switch p.Options.Type {
+	case "gzip":
+		level := gzip.DefaultCompression
+		if p.Options.Level != nil {
+			level = *p.Options.Level
+		}
+
+		gz, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+
+		w = gz
+	case "zlib":
+		level := zlib.DefaultCompression
+		if p.Options.Level != nil {
+			level = *p.Options.Level
+		}
+
+		z, err := zlib.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+
+		w = z
+	case "deflate":
+		level := flate.DefaultCompression
+		if p.Options.Level != nil {
+			level = *p.Options.Level
+		}
+
+		f, err := flate.NewWriter(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+
+		w = f
+	case "zstd":
+		return p.zstdEncoder.EncodeAll(data, nil), nil
+	case "snappy":
+		w = snappy.NewBufferedWriter(&buf)
+	case "lz4":
+		l := lz4.NewWriter(&buf)
+		if p.Options.Level != nil {
+			// lz4 levels are powers of two that start at 512 (Level1)
+			level := lz4.CompressionLevel(1 << (8 + *p.Options.Level))
+			if err := l.Apply(lz4.CompressionLevelOption(level)); err != nil {
+				return nil, err
+			}
+		}
+
+		w = l
+	case "brotli":
+		level := brotli.DefaultCompression
+		if p.Options.Level != nil {
+			level = *p.Options.Level
+		}
+
+		w = brotli.NewWriterLevel(&buf, level)
+	default:
+		return nil, fmt.Errorf("type %s: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
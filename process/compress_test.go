@@ -0,0 +1,261 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procCompress{}
+	_ Batcher = procCompress{}
+)
+
+var compressTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"zlib from",
+		config.Config{
+			Type: "compress",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"type":      "zlib",
+				},
+			},
+		},
+		[]byte("\x78\x9c\x4b\xcb\xcf\x07\x00\x02\x82\x01\x45"),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"bzip2 from",
+		config.Config{
+			Type: "compress",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"type":      "bzip2",
+				},
+			},
+		},
+		[]byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x49\xfe\xc4\xa5\x00\x00\x00\x01\x00\x01\x00\xa0\x00\x21\x00\x82\x2c\x5d\xc9\x14\xe1\x42\x41\x27\xfb\x12\x94"),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"auto from",
+		config.Config{
+			Type: "compress",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"type":      "auto",
+				},
+			},
+		},
+		[]byte("\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x49\xfe\xc4\xa5\x00\x00\x00\x01\x00\x01\x00\xa0\x00\x21\x00\x82\x2c\x5d\xc9\x14\xe1\x42\x41\x27\xfb\x12\x94"),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"auto uncompressed",
+		config.Config{
+			Type: "compress",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"type":      "auto",
+				},
+			},
+		},
+		[]byte(`foo`),
+		[]byte(`foo`),
+		nil,
+	},
+}
+
+func TestCompress(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range compressTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcCompress(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	ctx := context.TODO()
+	data := bytes.Repeat([]byte(`{"foo":"bar"}`), 100)
+
+	var tests = []struct {
+		name  string
+		level interface{}
+		auto  bool
+	}{
+		{"gzip", 9, true},
+		{"zlib", 1, false},
+		{"deflate", nil, false},
+		{"zstd", 19, true},
+		{"snappy", nil, true},
+		{"lz4", 9, true},
+		{"brotli", 11, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			to, err := newProcCompress(ctx, config.Config{
+				Type: "compress",
+				Settings: map[string]interface{}{
+					"options": map[string]interface{}{
+						"direction": "to",
+						"type":      test.name,
+						"level":     test.level,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fromType := test.name
+			if test.auto {
+				fromType = "auto"
+			}
+
+			from, err := newProcCompress(ctx, config.Config{
+				Type: "compress",
+				Settings: map[string]interface{}{
+					"options": map[string]interface{}{
+						"direction": "from",
+						"type":      fromType,
+					},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			capsule := config.NewCapsule()
+			capsule.SetData(data)
+
+			compressed, err := to.Apply(ctx, capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(compressed.Data()) >= len(data) {
+				t.Errorf("expected compressed data, got %d bytes", len(compressed.Data()))
+			}
+
+			result, err := from.Apply(ctx, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Data(), data) {
+				t.Errorf("expected %s, got %s", data, result.Data())
+			}
+		})
+	}
+}
+
+func TestCompressInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, options := range []map[string]interface{}{
+		{"direction": "to", "type": "bzip2"},
+		{"direction": "to", "type": "auto"},
+		{"direction": "to", "type": "gzip", "level": 10},
+		{"direction": "from", "type": "rar"},
+	} {
+		_, err := newProcCompress(ctx, config.Config{
+			Type: "compress",
+			Settings: map[string]interface{}{
+				"options": options,
+			},
+		})
+		if err == nil {
+			t.Errorf("expected error for options %v", options)
+		}
+	}
+}
+
+func TestCompressNoCompression(t *testing.T) {
+	ctx := context.TODO()
+	data := bytes.Repeat([]byte(`{"foo":"bar"}`), 100)
+
+	proc, err := newProcCompress(ctx, config.Config{
+		Type: "compress",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"direction": "to",
+				"type":      "gzip",
+				"level":     0,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData(data)
+
+	result, err := proc.Apply(ctx, capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// uncompressed data is stored as-is in the gzip stream
+	if !bytes.Contains(result.Data(), data) {
+		t.Errorf("expected uncompressed data, got %d bytes", len(result.Data()))
+	}
+}
+
+func benchmarkCompress(b *testing.B, applier procCompress, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range compressTests {
+		proc, err := newProcCompress(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkCompress(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,393 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/json"
+	"github.com/brexhq/substation/internal/kv"
+)
+
+// correlateKeyEscaper escapes characters in merged keys that have special
+// meaning in JSON paths.
+var correlateKeyEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`.`, `\.`,
+	`*`, `\*`,
+	`?`, `\?`,
+	`#`, `\#`,
+	`|`, `\|`,
+)
+
+// correlate processes data by joining related objects into sessions. Objects
+// belong to the same session if they share the value of the key, and sessions
+// are stored in a KV store until they are closed. Sessions are closed when:
+//
+// - an object matches the close condition (the object is included in the
+// session)
+//
+// - no objects were added to the session within the timeout
+//
+// - the session contains the maximum number of objects
+//
+// When a session is closed it is emitted as a new object, and objects that
+// are added to sessions are not emitted by the processor. Objects that do not
+// match the processor's condition are emitted unchanged.
+//
+// Sessions that time out are emitted by the next batch that either contains
+// an object for the session or is processed after the timeout. If the KV
+// store is shared (e.g., the aws_dynamodb KV store), then timed out sessions
+// are only emitted by processors that receive objects for the session.
+//
+// This processor supports the object handling pattern.
+type procCorrelate struct {
+	process
+	Options procCorrelateOptions `json:"options"`
+
+	kvStore kv.Storer
+	close   condition.Operator
+	// open tracks sessions updated by the processor so that they can be
+	// emitted when they time out.
+	open *correlateIndex
+}
+
+type procCorrelateOptions struct {
+	// Type determines how objects are added to a session.
+	//
+	// Must be one of:
+	//
+	// - append: objects are appended to an array
+	//
+	// - merge: objects are merged into a single object (values in later
+	// objects replace values in earlier objects)
+	Type string `json:"type"`
+	// Close is a condition that closes a session when an object matches it.
+	//
+	// This is optional and defaults to only closing sessions after the
+	// timeout or when they are full.
+	Close condition.Config `json:"close"`
+	// OffsetTTL is the time (in seconds) after the last object was added
+	// to a session that the session times out.
+	OffsetTTL int `json:"offset_ttl"`
+	// MaxCount is the maximum number of objects in a session.
+	//
+	// This is optional and defaults to 1000 objects.
+	MaxCount int `json:"max_count"`
+	// Prefix is prepended to session keys and is intended to simplify
+	// data management within a KV store.
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+	// KVOptions determine the type of KV store used by the processor. Refer
+	// to internal/kv for more information.
+	KVOptions config.Config `json:"kv_options"`
+}
+
+// correlateSession is a session stored in a KV store.
+type correlateSession struct {
+	Expires int64             `json:"expires"`
+	Count   int               `json:"count"`
+	Data    gojson.RawMessage `json:"data"`
+}
+
+// correlateIndex maps session keys to their expiration time.
+type correlateIndex struct {
+	mu       sync.Mutex
+	sessions map[string]int64
+}
+
+// Create a new correlate processor.
+func newProcCorrelate(ctx context.Context, cfg config.Config) (p procCorrelate, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procCorrelate{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procCorrelate{}, err
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"append",
+			"merge",
+		},
+		p.Options.Type) {
+		return procCorrelate{}, fmt.Errorf("process: correlate: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	// error early if required options are missing
+	if p.Key == "" || p.Options.OffsetTTL <= 0 || p.Options.KVOptions.Type == "" {
+		return procCorrelate{}, fmt.Errorf("process: correlate: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	// an empty condition always matches, so it is not used
+	if len(p.Options.Close.Inspectors) > 0 {
+		p.close, err = condition.NewOperator(ctx, p.Options.Close)
+		if err != nil {
+			return procCorrelate{}, err
+		}
+	}
+
+	if p.Options.MaxCount == 0 {
+		p.Options.MaxCount = 1000
+	}
+
+	p.kvStore, err = kv.Get(p.Options.KVOptions)
+	if err != nil {
+		return procCorrelate{}, fmt.Errorf("process: correlate: %v", err)
+	}
+
+	// lazy load the KV store
+	if !p.kvStore.IsEnabled() {
+		if err := p.kvStore.Setup(ctx); err != nil {
+			return procCorrelate{}, fmt.Errorf("process: correlate: %v", err)
+		}
+	}
+
+	p.open = &correlateIndex{sessions: make(map[string]int64)}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procCorrelate) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procCorrelate) Close(context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if p.kvStore.IsEnabled() {
+		if err := p.kvStore.Close(); err != nil {
+			return fmt.Errorf("close: correlate: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procCorrelate) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	p.open.mu.Lock()
+	defer p.open.mu.Unlock()
+
+	now := time.Now().Unix()
+
+	newCapsules := newBatch(&capsules)
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+
+		if !ok {
+			newCapsules = append(newCapsules, capsule)
+			continue
+		}
+
+		key := capsule.Get(p.Key).String()
+		if p.Options.Prefix != "" {
+			key = fmt.Sprint(p.Options.Prefix, ":", key)
+		}
+
+		session, err := p.get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+
+		// timed out sessions are emitted before a new session is started
+		if session != nil && session.Expires <= now {
+			emitted, err := p.emit(session)
+			if err != nil {
+				return nil, fmt.Errorf("process: correlate: %v", err)
+			}
+
+			newCapsules = append(newCapsules, emitted)
+			session = nil
+		}
+
+		if session == nil {
+			session = &correlateSession{}
+		}
+
+		if err := p.add(session, capsule.Data()); err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+		session.Expires = now + int64(p.Options.OffsetTTL)
+
+		closed := session.Count >= p.Options.MaxCount
+		if !closed && p.close != nil {
+			closed, err = p.close.Operate(ctx, capsule)
+			if err != nil {
+				return nil, fmt.Errorf("process: correlate: %v", err)
+			}
+		}
+
+		if closed {
+			emitted, err := p.emit(session)
+			if err != nil {
+				return nil, fmt.Errorf("process: correlate: %v", err)
+			}
+
+			newCapsules = append(newCapsules, emitted)
+			if err := p.delete(ctx, key); err != nil {
+				return nil, fmt.Errorf("process: correlate: %v", err)
+			}
+
+			continue
+		}
+
+		if err := p.put(ctx, key, session); err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+	}
+
+	// sessions that were not updated in this batch may have timed out, and
+	// they are emitted in a consistent order
+	var expired []string
+	for key, expires := range p.open.sessions {
+		if expires <= now {
+			expired = append(expired, key)
+		}
+	}
+	sort.Strings(expired)
+
+	for _, key := range expired {
+		session, err := p.get(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+
+		if session == nil {
+			delete(p.open.sessions, key)
+			continue
+		}
+
+		// the session was updated by another processor
+		if session.Expires > now {
+			p.open.sessions[key] = session.Expires
+			continue
+		}
+
+		emitted, err := p.emit(session)
+		if err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+
+		newCapsules = append(newCapsules, emitted)
+		if err := p.delete(ctx, key); err != nil {
+			return nil, fmt.Errorf("process: correlate: %v", err)
+		}
+	}
+
+	return newCapsules, nil
+}
+
+// add appends or merges an object into a session.
+func (p procCorrelate) add(session *correlateSession, data []byte) error {
+	var err error
+	session.Count++
+
+	switch p.Options.Type {
+	case "append":
+		if session.Data == nil {
+			session.Data = []byte(`[]`)
+		}
+
+		session.Data, err = json.SetRaw(session.Data, "-1", data)
+	case "merge":
+		if session.Data == nil {
+			session.Data = []byte(`{}`)
+		}
+
+		json.Get(data, "@this").ForEach(func(k, v json.Result) bool {
+			// keys that contain path characters are not nested
+			key := correlateKeyEscaper.Replace(k.String())
+			session.Data, err = json.SetRaw(session.Data, key, v.Raw)
+
+			return err == nil
+		})
+	}
+
+	return err
+}
+
+// emit returns a session as a capsule.
+func (p procCorrelate) emit(session *correlateSession) (config.Capsule, error) {
+	capsule := config.NewCapsule()
+	if p.SetKey == "" {
+		capsule.SetData(session.Data)
+		return capsule, nil
+	}
+
+	if err := capsule.SetRaw(p.SetKey, []byte(session.Data)); err != nil {
+		return capsule, err
+	}
+
+	return capsule, nil
+}
+
+// get retrieves a session from the KV store. Nil is returned if no session
+// exists.
+func (p procCorrelate) get(ctx context.Context, key string) (*correlateSession, error) {
+	v, err := p.kvStore.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return nil, nil
+	}
+
+	var session correlateSession
+	if err := gojson.Unmarshal([]byte(s), &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// put stores a session in the KV store. Sessions are kept in the store for
+// twice the timeout so that timed out sessions can be emitted.
+func (p procCorrelate) put(ctx context.Context, key string, session *correlateSession) error {
+	b, err := gojson.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	ttl := session.Expires + int64(p.Options.OffsetTTL)
+	if err := p.kvStore.SetWithTTL(ctx, key, string(b), ttl); err != nil {
+		return err
+	}
+
+	p.open.sessions[key] = session.Expires
+	return nil
+}
+
+// delete removes a session from the KV store. KV stores do not support
+// deletion, so the session is replaced by an empty value that expires
+// immediately.
+func (p procCorrelate) delete(ctx context.Context, key string) error {
+	delete(p.open.sessions, key)
+
+	return p.kvStore.SetWithTTL(ctx, key, "", time.Now().Unix())
+}
@@ -0,0 +1,306 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/config"
+)
+
+var _ Batcher = procCorrelate{}
+
+var correlateTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+	err      error
+}{
+	{
+		"append",
+		config.Config{
+			Type: "correlate",
+			Settings: map[string]interface{}{
+				"key":     "user",
+				"set_key": "session",
+				"options": map[string]interface{}{
+					"type":       "append",
+					"offset_ttl": 600,
+					"close": map[string]interface{}{
+						"operator": "all",
+						"inspectors": []config.Config{
+							{
+								Type: "strings",
+								Settings: map[string]interface{}{
+									"key": "event",
+									"options": map[string]interface{}{
+										"type":       "equals",
+										"expression": "mfa_failure",
+									},
+								},
+							},
+						},
+					},
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 201,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"user":"foo","event":"login"}`),
+			[]byte(`{"user":"bar","event":"login"}`),
+			[]byte(`{"user":"foo","event":"mfa_failure"}`),
+		},
+		[][]byte{
+			[]byte(`{"session":[{"user":"foo","event":"login"},{"user":"foo","event":"mfa_failure"}]}`),
+		},
+		nil,
+	},
+	{
+		"merge",
+		config.Config{
+			Type: "correlate",
+			Settings: map[string]interface{}{
+				"key": "flow",
+				"options": map[string]interface{}{
+					"type":       "merge",
+					"offset_ttl": 600,
+					"max_count":  2,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 202,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"flow":"a","syn":true}`),
+			[]byte(`{"flow":"a","fin":true,"bytes":10}`),
+		},
+		[][]byte{
+			[]byte(`{"flow":"a","syn":true,"fin":true,"bytes":10}`),
+		},
+		nil,
+	},
+	{
+		"merge special keys",
+		config.Config{
+			Type: "correlate",
+			Settings: map[string]interface{}{
+				"key": "flow",
+				"options": map[string]interface{}{
+					"type":       "merge",
+					"offset_ttl": 600,
+					"max_count":  2,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 205,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"flow":"a","a.b":1,"c*":2}`),
+			[]byte(`{"flow":"a","d?":3,"e#":4,"f|g":5}`),
+		},
+		[][]byte{
+			[]byte(`{"flow":"a","a.b":1,"c*":2,"d?":3,"e#":4,"f|g":5}`),
+		},
+		nil,
+	},
+	{
+		"condition",
+		config.Config{
+			Type: "correlate",
+			Settings: map[string]interface{}{
+				"key": "user",
+				"condition": map[string]interface{}{
+					"operator": "all",
+					"inspectors": []config.Config{
+						{
+							Type: "strings",
+							Settings: map[string]interface{}{
+								"key": "event",
+								"options": map[string]interface{}{
+									"type":       "equals",
+									"expression": "login",
+								},
+							},
+						},
+					},
+				},
+				"options": map[string]interface{}{
+					"type":       "append",
+					"offset_ttl": 600,
+					"max_count":  1,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 203,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"user":"foo","event":"logout"}`),
+			[]byte(`{"user":"foo","event":"login"}`),
+		},
+		[][]byte{
+			[]byte(`{"user":"foo","event":"logout"}`),
+			[]byte(`[{"user":"foo","event":"login"}]`),
+		},
+		nil,
+	},
+}
+
+func TestCorrelate(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range correlateTests {
+		t.Run(test.name, func(t *testing.T) {
+			var capsules []config.Capsule
+			for _, t := range test.test {
+				capsule.SetData(t)
+				capsules = append(capsules, capsule)
+			}
+
+			proc, err := newProcCorrelate(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// closing the processor resets the KV store
+			defer proc.Close(ctx)
+
+			result, err := proc.Batch(ctx, capsules...)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d, got %d", len(test.expected), len(result))
+			}
+
+			for i, res := range result {
+				if !bytes.Equal(res.Data(), test.expected[i]) {
+					t.Errorf("expected %s, got %s", test.expected[i], res.Data())
+				}
+			}
+		})
+	}
+}
+
+func TestCorrelateTimeout(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcCorrelate(ctx, config.Config{
+		Type: "correlate",
+		Settings: map[string]interface{}{
+			"key": "user",
+			"options": map[string]interface{}{
+				"type":       "append",
+				"offset_ttl": 600,
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 204,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	// sessions are put directly into the store to avoid waiting for them
+	// to time out
+	for _, key := range []string{"foo", "bar"} {
+		session := &correlateSession{
+			Expires: time.Now().Unix() - 1,
+			Count:   1,
+			Data:    []byte(`[{"user":"` + key + `"}]`),
+		}
+
+		if err := proc.put(ctx, key, session); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`{"user":"foo","event":"login"}`))
+
+	result, err := proc.Batch(ctx, capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the timed out foo session is emitted when a new object is received
+	// and the timed out bar session is emitted at the end of the batch
+	expected := [][]byte{
+		[]byte(`[{"user":"foo"}]`),
+		[]byte(`[{"user":"bar"}]`),
+	}
+
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d, got %d", len(expected), len(result))
+	}
+
+	for i, res := range result {
+		if !bytes.Equal(res.Data(), expected[i]) {
+			t.Errorf("expected %s, got %s", expected[i], res.Data())
+		}
+	}
+
+	// the new foo session is still open
+	session, err := proc.get(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session == nil || session.Count != 1 {
+		t.Errorf("expected open session, got %+v", session)
+	}
+}
+
+func benchmarkCorrelate(b *testing.B, batcher procCorrelate, capsules []config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = batcher.Batch(ctx, capsules...)
+	}
+}
+
+func BenchmarkCorrelate(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range correlateTests {
+		var capsules []config.Capsule
+		for _, t := range test.test {
+			capsule.SetData(t)
+			capsules = append(capsules, capsule)
+		}
+
+		proc, err := newProcCorrelate(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkCorrelate(b, proc, capsules)
+			},
+		)
+	}
+}
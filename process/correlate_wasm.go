@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procCorrelate struct {
+	process
+	Options procCorrelateOptions `json:"options"`
+}
+
+type procCorrelateOptions struct{}
+
+func newProcCorrelate(ctx context.Context, cfg config.Config) (p procCorrelate, err error) {
+	return procCorrelate{}, fmt.Errorf("process: correlate: %v", syscall.ENOSYS)
+}
+
+func (p procCorrelate) String() string {
+	return toString(p)
+}
+
+func (p procCorrelate) Close(ctx context.Context) error {
+	return fmt.Errorf("close: correlate: %v", syscall.ENOSYS)
+}
+
+func (p procCorrelate) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procCorrelate) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: correlate: %v", syscall.ENOSYS)
+}
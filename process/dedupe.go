@@ -0,0 +1,226 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
+)
+
+// dedupe processes data by identifying duplicate data that was seen within a
+// time window. Duplicates are identified using a fingerprint (SHA-256 hash)
+// of either selected keys or the entire data, and fingerprints are put into a
+// KV store with a time-to-live (TTL). If the KV store is shared (e.g., the
+// aws_dynamodb KV store), then duplicates are identified across multiple
+// invocations of the processor.
+//
+// Duplicates are either dropped or tagged. Data that does not match the
+// processor's condition is never considered a duplicate.
+//
+// This processor supports the data and object handling patterns.
+type procDedupe struct {
+	process
+	Options procDedupeOptions `json:"options"`
+
+	kvStore kv.Storer
+}
+
+type procDedupeOptions struct {
+	// Keys are the keys used to create the fingerprint of an object.
+	//
+	// This is optional and defaults to using the entire data.
+	Keys []string `json:"keys"`
+	// Action determines what happens to duplicates.
+	//
+	// Must be one of:
+	//
+	// - drop: duplicates are removed from the batch
+	//
+	// - tag: the set key is set to true for duplicates and false for
+	// all other data
+	Action string `json:"action"`
+	// OffsetTTL is an offset (in seconds) used to determine the time-to-live
+	// (TTL) of fingerprints. Data is a duplicate if its fingerprint was
+	// seen within this window.
+	OffsetTTL int `json:"offset_ttl"`
+	// Prefix is prepended to fingerprints and is intended to simplify
+	// data management within a KV store.
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+	// KVOptions determine the type of KV store used by the processor. Refer
+	// to internal/kv for more information.
+	KVOptions config.Config `json:"kv_options"`
+}
+
+// Create a new dedupe processor.
+func newProcDedupe(ctx context.Context, cfg config.Config) (p procDedupe, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procDedupe{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procDedupe{}, err
+	}
+
+	//  validate option.action
+	if !slices.Contains(
+		[]string{
+			"drop",
+			"tag",
+		},
+		p.Options.Action) {
+		return procDedupe{}, fmt.Errorf("process: dedupe: action %q: %v", p.Options.Action, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Action == "tag" && p.SetKey == "" {
+		return procDedupe{}, fmt.Errorf("process: dedupe: action %s set_key %s: %v", p.Options.Action, p.SetKey, errInvalidDataPattern)
+	}
+
+	if p.Options.OffsetTTL <= 0 || p.Options.KVOptions.Type == "" {
+		return procDedupe{}, fmt.Errorf("process: dedupe: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	p.kvStore, err = kv.Get(p.Options.KVOptions)
+	if err != nil {
+		return procDedupe{}, fmt.Errorf("process: dedupe: %v", err)
+	}
+
+	// lazy load the KV store
+	if !p.kvStore.IsEnabled() {
+		if err := p.kvStore.Setup(ctx); err != nil {
+			return procDedupe{}, fmt.Errorf("process: dedupe: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procDedupe) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procDedupe) Close(context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if p.kvStore.IsEnabled() {
+		if err := p.kvStore.Close(); err != nil {
+			return fmt.Errorf("close: dedupe: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procDedupe) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	newCapsules := newBatch(&capsules)
+
+	// fingerprints seen in the batch are tracked separately to avoid
+	// unnecessary requests to the KV store
+	seen := make(map[string]struct{})
+	now := time.Now()
+	ttl := now.Add(time.Duration(p.Options.OffsetTTL) * time.Second).Unix()
+
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: dedupe: %v", err)
+		}
+
+		duplicate := false
+		if ok {
+			fp := p.fingerprint(capsule)
+			if _, found := seen[fp]; found {
+				duplicate = true
+			} else {
+				v, err := p.kvStore.Get(ctx, fp)
+				if err != nil {
+					return nil, fmt.Errorf("process: dedupe: %v", err)
+				}
+
+				duplicate = dedupeUnexpired(v, now)
+				seen[fp] = struct{}{}
+			}
+
+			// the TTL is always reset so that the window starts
+			// from the last time the data was seen
+			if err := p.kvStore.SetWithTTL(ctx, fp, ttl, ttl); err != nil {
+				return nil, fmt.Errorf("process: dedupe: %v", err)
+			}
+		}
+
+		switch p.Options.Action {
+		case "drop":
+			if duplicate {
+				continue
+			}
+		case "tag":
+			if err := capsule.Set(p.SetKey, duplicate); err != nil {
+				return nil, fmt.Errorf("process: dedupe: %v", err)
+			}
+		}
+
+		newCapsules = append(newCapsules, capsule)
+	}
+
+	return newCapsules, nil
+}
+
+// fingerprint returns the SHA-256 hash of the configured keys or the data,
+// prepended with the prefix.
+func (p procDedupe) fingerprint(capsule config.Capsule) string {
+	h := sha256.New()
+	if len(p.Options.Keys) == 0 {
+		h.Write(capsule.Data())
+	} else {
+		for _, key := range p.Options.Keys {
+			// keys are included so that values in different keys
+			// produce different fingerprints
+			h.Write([]byte(key))
+			h.Write([]byte{0})
+			h.Write([]byte(capsule.Get(key).Raw))
+			h.Write([]byte{0})
+		}
+	}
+
+	fp := fmt.Sprintf("%x", h.Sum(nil))
+	if p.Options.Prefix != "" {
+		fp = fmt.Sprint(p.Options.Prefix, ":", fp)
+	}
+
+	return fp
+}
+
+// dedupeUnexpired returns true if a value retrieved from the KV store is an
+// expiration time that has not passed. Some KV stores (e.g., DynamoDB) do not
+// immediately delete items after their TTL has passed, so the expiration time
+// is stored as the value and checked when it is retrieved.
+func dedupeUnexpired(v interface{}, now time.Time) bool {
+	var exp int64
+	switch t := v.(type) {
+	case int64:
+		exp = t
+	case float64:
+		exp = int64(t)
+	default:
+		return false
+	}
+
+	return exp > now.Unix()
+}
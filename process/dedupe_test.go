@@ -0,0 +1,217 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var _ Batcher = procDedupe{}
+
+var dedupeTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+	err      error
+}{
+	{
+		"drop",
+		config.Config{
+			Type: "dedupe",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"action":     "drop",
+					"offset_ttl": 60,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 101,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1}`),
+			[]byte(`{"foo":"bar","baz":2}`),
+			[]byte(`{"foo":"bar","baz":1}`),
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1}`),
+			[]byte(`{"foo":"bar","baz":2}`),
+		},
+		nil,
+	},
+	{
+		"drop keys",
+		config.Config{
+			Type: "dedupe",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"action":     "drop",
+					"keys":       []string{"foo"},
+					"offset_ttl": 60,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 102,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1}`),
+			[]byte(`{"foo":"bar","baz":2}`),
+			[]byte(`{"foo":"qux","baz":1}`),
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1}`),
+			[]byte(`{"foo":"qux","baz":1}`),
+		},
+		nil,
+	},
+	{
+		"tag",
+		config.Config{
+			Type: "dedupe",
+			Settings: map[string]interface{}{
+				"set_key": "duplicate",
+				"options": map[string]interface{}{
+					"action":     "tag",
+					"keys":       []string{"foo", "baz"},
+					"offset_ttl": 60,
+					"kv_options": map[string]interface{}{
+						"type": "memory",
+						"settings": map[string]interface{}{
+							"capacity": 103,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1}`),
+			[]byte(`{"foo":"bar","baz":1}`),
+		},
+		[][]byte{
+			[]byte(`{"foo":"bar","baz":1,"duplicate":false}`),
+			[]byte(`{"foo":"bar","baz":1,"duplicate":true}`),
+		},
+		nil,
+	},
+}
+
+func TestDedupe(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range dedupeTests {
+		t.Run(test.name, func(t *testing.T) {
+			var capsules []config.Capsule
+			for _, t := range test.test {
+				capsule.SetData(t)
+				capsules = append(capsules, capsule)
+			}
+
+			proc, err := newProcDedupe(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// closing the processor resets the KV store
+			defer proc.Close(ctx)
+
+			result, err := proc.Batch(ctx, capsules...)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d, got %d", len(test.expected), len(result))
+			}
+
+			for i, res := range result {
+				if !bytes.Equal(res.Data(), test.expected[i]) {
+					t.Errorf("expected %s, got %s", test.expected[i], res.Data())
+				}
+			}
+		})
+	}
+}
+
+func TestDedupeInvocations(t *testing.T) {
+	ctx := context.TODO()
+
+	cfg := config.Config{
+		Type: "dedupe",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"action":     "drop",
+				"offset_ttl": 60,
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+					"settings": map[string]interface{}{
+						"capacity": 104,
+					},
+				},
+			},
+		},
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`{"foo":"bar"}`))
+
+	// duplicates are found across processors that share a KV store
+	for i, expected := range []int{1, 0} {
+		proc, err := newProcDedupe(ctx, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := proc.Batch(ctx, capsule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result) != expected {
+			t.Errorf("invocation %d: expected %d, got %d", i, expected, len(result))
+		}
+
+		if i == 1 {
+			_ = proc.Close(ctx)
+		}
+	}
+}
+
+func benchmarkDedupe(b *testing.B, batcher procDedupe, capsules []config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = batcher.Batch(ctx, capsules...)
+	}
+}
+
+func BenchmarkDedupe(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range dedupeTests {
+		var capsules []config.Capsule
+		for _, t := range test.test {
+			capsule.SetData(t)
+			capsules = append(capsules, capsule)
+		}
+
+		proc, err := newProcDedupe(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkDedupe(b, proc, capsules)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procDedupe struct {
+	process
+	Options procDedupeOptions `json:"options"`
+}
+
+type procDedupeOptions struct{}
+
+func newProcDedupe(ctx context.Context, cfg config.Config) (p procDedupe, err error) {
+	return procDedupe{}, fmt.Errorf("process: dedupe: %v", syscall.ENOSYS)
+}
+
+func (p procDedupe) String() string {
+	return toString(p)
+}
+
+func (p procDedupe) Close(ctx context.Context) error {
+	return fmt.Errorf("close: dedupe: %v", syscall.ENOSYS)
+}
+
+func (p procDedupe) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procDedupe) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: dedupe: %v", syscall.ENOSYS)
+}
@@ -4,19 +4,41 @@ package process
 
 import (
 	"context"
+	gojson "encoding/json"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"golang.org/x/exp/slices"
+	"golang.org/x/net/dns/dnsmessage"
 
 	"github.com/brexhq/substation/condition"
 	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/dns"
 	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
 )
 
 var dnsResolver net.Resolver
 
+// dnsTypeCAA is the CAA record type (RFC 8659), which is not defined by the
+// dnsmessage package.
+const dnsTypeCAA = dnsmessage.Type(257)
+
+// dnsQueryTypes maps query types to the record types that are queried.
+var dnsQueryTypes = map[string][]dnsmessage.Type{
+	"forward_lookup": {dnsmessage.TypeA, dnsmessage.TypeAAAA},
+	"reverse_lookup": {dnsmessage.TypePTR},
+	"query_txt":      {dnsmessage.TypeTXT},
+	"query_mx":       {dnsmessage.TypeMX},
+	"query_ns":       {dnsmessage.TypeNS},
+	"query_cname":    {dnsmessage.TypeCNAME},
+	"query_soa":      {dnsmessage.TypeSOA},
+	"query_srv":      {dnsmessage.TypeSRV},
+	"query_caa":      {dnsTypeCAA},
+}
+
 // dns processes data by querying domains or IP addresses in the Domain Name
 // System (DNS). By default, this processor can take up to 1 second per DNS
 // query and may have significant impact on end-to-end data processing latency.
@@ -25,9 +47,17 @@ var dnsResolver net.Resolver
 //	mitigated by increasing the parallelization factor of the Lambda
 //
 // (https://docs.aws.amazon.com/lambda/latest/dg/with-kinesis.html).
+//
+// Latency can also be mitigated by caching responses in a KV store.
+//
+// Failed queries do not modify the data by default. For compatibility with
+// existing configurations, errors are returned only if IgnoreErrors is true.
 type procDNS struct {
 	process
 	Options procDNSOptions `json:"options"`
+
+	client  *dns.Client
+	kvStore kv.Storer
 }
 
 type procDNSOptions struct {
@@ -40,12 +70,61 @@ type procDNSOptions struct {
 	// - reverse_lookup: retrieve domains associated with an IP address
 	//
 	// - query_txt: retrieve TXT records for a domain
+	//
+	// - query_mx: retrieve MX records for a domain
+	//
+	// - query_ns: retrieve NS records for a domain
+	//
+	// - query_cname: retrieve CNAME records for a domain
+	//
+	// - query_soa: retrieve SOA records for a domain
+	//
+	// - query_srv: retrieve SRV records for a service (e.g.,
+	// _ldap._tcp.example.com)
+	//
+	// - query_caa: retrieve CAA records for a domain
+	//
+	// The forward_lookup, reverse_lookup, and query_txt types return a list of
+	// strings. All other types return a list of objects that contain the
+	// values and the time-to-live (TTL) of each record. For example, query_mx
+	// returns [{"host":"mx.example.com.","preference":10,"ttl":300}].
 	Type string `json:"type"`
 	// Timeout is the amount of time to wait (in milliseconds) for
 	// a response.
 	//
 	// This is optional and defaults to 1000 milliseconds (1 second).
 	Timeout int `json:"timeout"`
+	// Resolver is the address of the DNS resolver that is queried.
+	//
+	// Must be one of:
+	//
+	// - host or host:port: the resolver is queried over UDP
+	//
+	// - udp://host:port: the resolver is queried over UDP
+	//
+	// - tcp://host:port: the resolver is queried over TCP
+	//
+	// - https://host/path: the resolver is queried using DNS over HTTPS
+	//
+	// This is optional and defaults to the system resolver. If the port is
+	// not provided, then it defaults to 53.
+	Resolver string `json:"resolver"`
+	// KVOptions determine the type of KV store used to cache responses. Each
+	// response is cached until the lowest TTL of its records has passed and
+	// TTLs of cached records are reduced by the time spent in the cache.
+	// Refer to internal/kv for more information.
+	//
+	// This is optional and responses are not cached by default. Responses
+	// from the system resolver for the forward_lookup, reverse_lookup, and
+	// query_txt types are never cached because they do not contain TTLs.
+	KVOptions config.Config `json:"kv_options"`
+}
+
+// dnsCache is a response that is stored in a KV store.
+type dnsCache struct {
+	// Time is when the response was cached (in Unix seconds).
+	Time    int64                    `json:"time"`
+	Records []map[string]interface{} `json:"records"`
 }
 
 // Create a new DNS processor.
@@ -65,6 +144,12 @@ func newProcDNS(ctx context.Context, cfg config.Config) (p procDNS, err error) {
 			"forward_lookup",
 			"reverse_lookup",
 			"query_txt",
+			"query_mx",
+			"query_ns",
+			"query_cname",
+			"query_soa",
+			"query_srv",
+			"query_caa",
 		},
 		p.Options.Type) {
 		return procDNS{}, fmt.Errorf("process: dns: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
@@ -76,11 +161,48 @@ func newProcDNS(ctx context.Context, cfg config.Config) (p procDNS, err error) {
 		return procDNS{}, fmt.Errorf("process: dns: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
 	}
 
+	// the system resolver only supports the original query types
+	if p.Options.Resolver != "" || (strings.HasPrefix(p.Options.Type, "query_") && p.Options.Type != "query_txt") {
+		p.client, err = dns.New(p.Options.Resolver)
+		if err != nil {
+			return procDNS{}, fmt.Errorf("process: dns: %v", err)
+		}
+	}
+
+	if p.Options.KVOptions.Type != "" {
+		p.kvStore, err = kv.Get(p.Options.KVOptions)
+		if err != nil {
+			return procDNS{}, fmt.Errorf("process: dns: %v", err)
+		}
+
+		// lazy load the KV store
+		if !p.kvStore.IsEnabled() {
+			if err := p.kvStore.Setup(ctx); err != nil {
+				return procDNS{}, fmt.Errorf("process: dns: %v", err)
+			}
+		}
+	}
+
 	return p, nil
 }
 
+// String returns the processor settings as an object.
+func (p procDNS) String() string {
+	return toString(p)
+}
+
 // Closes resources opened by the processor.
 func (p procDNS) Close(context.Context) error {
+	if p.IgnoreClose || p.kvStore == nil {
+		return nil
+	}
+
+	if p.kvStore.IsEnabled() {
+		if err := p.kvStore.Close(); err != nil {
+			return fmt.Errorf("close: dns: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -91,8 +213,6 @@ func (p procDNS) Batch(ctx context.Context, capsules ...config.Capsule) ([]confi
 }
 
 // Apply processes a capsule with the processor.
-//
-//nolint:gocognit
 func (p procDNS) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
 	var timeout time.Duration
 	if p.Options.Timeout != 0 {
@@ -105,86 +225,239 @@ func (p procDNS) Apply(ctx context.Context, capsule config.Capsule) (config.Caps
 	defer cancel() // important to avoid a resource leak
 
 	// JSON processing
-	//nolint: nestif // ignore nesting
 	if p.Key != "" && p.SetKey != "" {
 		res := capsule.Get(p.Key).String()
 
-		switch p.Options.Type {
-		case "forward_lookup":
-			addrs, err := dnsResolver.LookupHost(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
-			}
-
-			if err := capsule.Set(p.SetKey, addrs); err != nil {
+		value, err := p.query(resolverCtx, res)
+		// errors are only returned if IgnoreErrors is true, which is
+		// preserved for compatibility with existing configurations.
+		if err != nil {
+			if p.IgnoreErrors {
 				return capsule, fmt.Errorf("process: dns: %v", err)
 			}
 
 			return capsule, nil
-		case "reverse_lookup":
-			names, err := dnsResolver.LookupAddr(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
-			}
+		}
+
+		if err := capsule.Set(p.SetKey, value); err != nil {
+			return capsule, fmt.Errorf("process: dns: %v", err)
+		}
 
-			if err := capsule.Set(p.SetKey, names); err != nil {
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		res := string(capsule.Data())
+
+		value, err := p.query(resolverCtx, res)
+		// errors are only returned if IgnoreErrors is true, which is
+		// preserved for compatibility with existing configurations.
+		if err != nil {
+			if p.IgnoreErrors {
 				return capsule, fmt.Errorf("process: dns: %v", err)
 			}
 
 			return capsule, nil
-		case "query_txt":
-			records, err := dnsResolver.LookupTXT(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
-			}
+		}
 
-			if err := capsule.Set(p.SetKey, records); err != nil {
-				return capsule, fmt.Errorf("process: dns: %v", err)
+		// can only return one value, which is the first address, name, or record
+		switch v := value.(type) {
+		case []string:
+			if len(v) > 0 {
+				capsule.SetData([]byte(v[0]))
 			}
+		case []map[string]interface{}:
+			if len(v) > 0 {
+				b, err := gojson.Marshal(v[0])
+				if err != nil {
+					return capsule, fmt.Errorf("process: dns: %v", err)
+				}
 
-			return capsule, nil
-		default:
-			return capsule, nil
+				capsule.SetData(b)
+			}
 		}
+
+		return capsule, nil
 	}
 
-	// data processing
-	if p.Key == "" && p.SetKey == "" {
-		res := string(capsule.Data())
+	return capsule, fmt.Errorf("process: dns: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
 
+// query returns a list of strings for the forward_lookup, reverse_lookup, and
+// query_txt types, otherwise it returns a list of records.
+func (p procDNS) query(ctx context.Context, name string) (interface{}, error) {
+	if p.client == nil {
 		switch p.Options.Type {
 		case "forward_lookup":
-			addrs, err := dnsResolver.LookupHost(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
+			return dnsResolver.LookupHost(ctx, name)
+		case "reverse_lookup":
+			return dnsResolver.LookupAddr(ctx, name)
+		default:
+			return dnsResolver.LookupTXT(ctx, name)
+		}
+	}
+
+	records, err := p.records(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var field string
+	switch p.Options.Type {
+	case "forward_lookup":
+		field = "address"
+	case "reverse_lookup":
+		field = "host"
+	case "query_txt":
+		field = "text"
+	default:
+		return records, nil
+	}
+
+	values := make([]string, 0, len(records))
+	for _, r := range records {
+		values = append(values, fmt.Sprint(r[field]))
+	}
+
+	return values, nil
+}
+
+// cacheKey returns the key used to cache the records for a name. The
+// resolver is included because processors that use different resolvers can
+// share a KV store.
+func (p procDNS) cacheKey(name string) string {
+	return fmt.Sprint("dns:", p.Options.Resolver, ":", p.Options.Type, ":", name)
+}
+
+// records returns the records for a name from the cache or the resolver.
+func (p procDNS) records(ctx context.Context, name string) ([]map[string]interface{}, error) {
+	key := p.cacheKey(name)
+	if p.kvStore != nil {
+		val, err := p.kvStore.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if val != nil {
+			var cache dnsCache
+			if err := gojson.Unmarshal([]byte(fmt.Sprint(val)), &cache); err != nil {
+				return nil, err
 			}
 
-			// can only return one value, which is the first address
-			capsule.SetData([]byte(addrs[0]))
+			elapsed := float64(time.Now().Unix() - cache.Time)
+			for _, r := range cache.Records {
+				ttl, _ := r["ttl"].(float64)
+				if ttl -= elapsed; ttl < 0 {
+					ttl = 0
+				}
 
-			return capsule, nil
-		case "reverse_lookup":
-			names, err := dnsResolver.LookupAddr(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
+				r["ttl"] = uint32(ttl)
 			}
 
-			// can only return one value, which is the first name
-			capsule.SetData([]byte(names[0]))
-			return capsule, nil
-		case "query_txt":
-			records, err := dnsResolver.LookupTXT(resolverCtx, res)
-			if err != nil && p.IgnoreErrors {
-				return capsule, fmt.Errorf("process: dns: %v", err)
+			return cache.Records, nil
+		}
+	}
+
+	if p.Options.Type == "reverse_lookup" {
+		var err error
+		if name, err = dns.ReverseName(name); err != nil {
+			return nil, err
+		}
+	}
+
+	records := []map[string]interface{}{}
+	var minTTL uint32
+	for _, t := range dnsQueryTypes[p.Options.Type] {
+		answers, err := p.client.Query(ctx, name, t)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range answers {
+			if a.Header.Type != t {
+				continue
 			}
 
-			// can only return one value, which is the first record
-			capsule.SetData([]byte(records[0]))
-			return capsule, nil
-		default:
-			return capsule, nil
+			r := dnsRecord(a)
+			if r == nil {
+				continue
+			}
+
+			if len(records) == 0 || a.Header.TTL < minTTL {
+				minTTL = a.Header.TTL
+			}
+
+			records = append(records, r)
 		}
 	}
 
-	return capsule, fmt.Errorf("process: dns: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	// responses without records are not cached because their TTL is unknown
+	if p.kvStore != nil && len(records) > 0 && minTTL > 0 {
+		now := time.Now()
+		b, err := gojson.Marshal(dnsCache{Time: now.Unix(), Records: records})
+		if err != nil {
+			return nil, err
+		}
+
+		ttl := now.Add(time.Duration(minTTL) * time.Second).Unix()
+		if err := p.kvStore.SetWithTTL(ctx, key, string(b), ttl); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+// dnsRecord converts a resource record into an object. Nil is returned for
+// unsupported records.
+func dnsRecord(r dnsmessage.Resource) map[string]interface{} {
+	record := map[string]interface{}{
+		"ttl": r.Header.TTL,
+	}
+
+	switch b := r.Body.(type) {
+	case *dnsmessage.AResource:
+		record["address"] = net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		record["address"] = net.IP(b.AAAA[:]).String()
+	case *dnsmessage.PTRResource:
+		record["host"] = b.PTR.String()
+	case *dnsmessage.TXTResource:
+		record["text"] = strings.Join(b.TXT, "")
+	case *dnsmessage.MXResource:
+		record["host"] = b.MX.String()
+		record["preference"] = b.Pref
+	case *dnsmessage.NSResource:
+		record["host"] = b.NS.String()
+	case *dnsmessage.CNAMEResource:
+		record["host"] = b.CNAME.String()
+	case *dnsmessage.SOAResource:
+		record["ns"] = b.NS.String()
+		record["mbox"] = b.MBox.String()
+		record["serial"] = b.Serial
+		record["refresh"] = b.Refresh
+		record["retry"] = b.Retry
+		record["expire"] = b.Expire
+		record["min_ttl"] = b.MinTTL
+	case *dnsmessage.SRVResource:
+		record["target"] = b.Target.String()
+		record["port"] = b.Port
+		record["priority"] = b.Priority
+		record["weight"] = b.Weight
+	case *dnsmessage.UnknownResource:
+		// CAA records contain flags, a tag length, a tag, and a value
+		// (RFC 8659 section 4.1)
+		if b.Type != dnsTypeCAA || len(b.Data) < 2 || len(b.Data) < 2+int(b.Data[1]) {
+			return nil
+		}
+
+		record["flags"] = b.Data[0]
+		record["tag"] = string(b.Data[2 : 2+b.Data[1]])
+		record["value"] = string(b.Data[2+b.Data[1]:])
+	default:
+		return nil
+	}
+
+	return record
 }
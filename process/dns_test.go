@@ -0,0 +1,360 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procDNS{}
+	_ Batcher = procDNS{}
+)
+
+// dnsTestRecords are the records returned by dnsTestServer.
+var dnsTestRecords = map[string][]dnsmessage.ResourceBody{
+	"example.com. TypeA": {
+		&dnsmessage.AResource{A: [4]byte{93, 184, 216, 34}},
+	},
+	"example.com. TypeAAAA": {
+		&dnsmessage.AAAAResource{AAAA: [16]byte{0x26, 0x06, 0x28, 0x00, 0x02, 0x20, 0x00, 0x01, 0x02, 0x48, 0x18, 0x93, 0x25, 0xc8, 0x19, 0x46}},
+	},
+	"example.com. TypeMX": {
+		&dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx.example.com.")},
+	},
+	"example.com. TypeNS": {
+		&dnsmessage.NSResource{NS: dnsmessage.MustNewName("a.iana-servers.net.")},
+	},
+	"www.example.com. TypeCNAME": {
+		&dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.com.")},
+	},
+	"example.com. TypeSOA": {
+		&dnsmessage.SOAResource{
+			NS:      dnsmessage.MustNewName("ns.icann.org."),
+			MBox:    dnsmessage.MustNewName("noc.dns.icann.org."),
+			Serial:  2022091303,
+			Refresh: 7200,
+			Retry:   3600,
+			Expire:  1209600,
+			MinTTL:  3600,
+		},
+	},
+	"_ldap._tcp.example.com. TypeSRV": {
+		&dnsmessage.SRVResource{Priority: 0, Weight: 5, Port: 389, Target: dnsmessage.MustNewName("ldap.example.com.")},
+	},
+	"example.com. TypeTXT": {
+		&dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}},
+	},
+	"example.com. 257": {
+		&dnsmessage.UnknownResource{Type: dnsTypeCAA, Data: append([]byte{0, 5}, "issueletsencrypt.org"...)},
+	},
+	"34.216.184.93.in-addr.arpa. TypePTR": {
+		&dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("example.com.")},
+	},
+}
+
+// dnsTestServer starts a UDP server that responds to queries and returns its
+// address and a counter of the queries it received.
+func dnsTestServer(t testing.TB) (string, *int32) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var count int32
+	go func() {
+		buf := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			atomic.AddInt32(&count, 1)
+
+			var q dnsmessage.Message
+			if err := q.Unpack(buf[:n]); err != nil {
+				continue
+			}
+
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: q.Header.ID, Response: true},
+				Questions: q.Questions,
+			}
+
+			question := q.Questions[0]
+			records, ok := dnsTestRecords[question.Name.String()+" "+question.Type.String()]
+			if !ok {
+				resp.Header.RCode = dnsmessage.RCodeNameError
+			}
+
+			for _, r := range records {
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{
+						Name:  question.Name,
+						Type:  question.Type,
+						Class: dnsmessage.ClassINET,
+						TTL:   300,
+					},
+					Body: r,
+				})
+			}
+
+			b, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+
+			_, _ = conn.WriteTo(b, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), &count
+}
+
+var dnsTests = []struct {
+	name     string
+	typ      string
+	test     []byte
+	expected []byte
+}{
+	{
+		"forward_lookup",
+		"forward_lookup",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":["93.184.216.34","2606:2800:220:1:248:1893:25c8:1946"]}`),
+	},
+	{
+		"reverse_lookup",
+		"reverse_lookup",
+		[]byte(`{"foo":"93.184.216.34"}`),
+		[]byte(`{"foo":"93.184.216.34","bar":["example.com."]}`),
+	},
+	{
+		"query_txt",
+		"query_txt",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":["v=spf1 -all"]}`),
+	},
+	{
+		"query_mx",
+		"query_mx",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":[{"host":"mx.example.com.","preference":10,"ttl":300}]}`),
+	},
+	{
+		"query_ns",
+		"query_ns",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":[{"host":"a.iana-servers.net.","ttl":300}]}`),
+	},
+	{
+		"query_cname",
+		"query_cname",
+		[]byte(`{"foo":"www.example.com"}`),
+		[]byte(`{"foo":"www.example.com","bar":[{"host":"example.com.","ttl":300}]}`),
+	},
+	{
+		"query_soa",
+		"query_soa",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":[{"expire":1209600,"mbox":"noc.dns.icann.org.","min_ttl":3600,"ns":"ns.icann.org.","refresh":7200,"retry":3600,"serial":2022091303,"ttl":300}]}`),
+	},
+	{
+		"query_srv",
+		"query_srv",
+		[]byte(`{"foo":"_ldap._tcp.example.com"}`),
+		[]byte(`{"foo":"_ldap._tcp.example.com","bar":[{"port":389,"priority":0,"target":"ldap.example.com.","ttl":300,"weight":5}]}`),
+	},
+	{
+		"query_caa",
+		"query_caa",
+		[]byte(`{"foo":"example.com"}`),
+		[]byte(`{"foo":"example.com","bar":[{"flags":0,"tag":"issue","ttl":300,"value":"letsencrypt.org"}]}`),
+	},
+}
+
+func dnsConfig(typ, resolver string, settings map[string]interface{}) config.Config {
+	s := map[string]interface{}{
+		"key":     "foo",
+		"set_key": "bar",
+		"options": map[string]interface{}{
+			"type":     typ,
+			"resolver": resolver,
+		},
+	}
+
+	for k, v := range settings {
+		s[k] = v
+	}
+
+	return config.Config{
+		Type:     "dns",
+		Settings: s,
+	}
+}
+
+func TestDNS(t *testing.T) {
+	ctx := context.TODO()
+	addr, _ := dnsTestServer(t)
+
+	for _, test := range dnsTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule := config.NewCapsule()
+			capsule.SetData(test.test)
+
+			proc, err := newProcDNS(ctx, dnsConfig(test.typ, addr, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestDNSData(t *testing.T) {
+	ctx := context.TODO()
+	addr, _ := dnsTestServer(t)
+
+	proc, err := newProcDNS(ctx, dnsConfig("query_mx", addr, map[string]interface{}{
+		"key":     "",
+		"set_key": "",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`example.com`))
+
+	result, err := proc.Apply(ctx, capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte(`{"host":"mx.example.com.","preference":10,"ttl":300}`)
+	if !bytes.Equal(result.Data(), expected) {
+		t.Errorf("expected %s, got %s", expected, result.Data())
+	}
+}
+
+func TestDNSErrors(t *testing.T) {
+	ctx := context.TODO()
+	addr, _ := dnsTestServer(t)
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`{"foo":"missing.example.com"}`))
+
+	// errors are not returned by default
+	proc, err := newProcDNS(ctx, dnsConfig("query_mx", addr, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := proc.Apply(ctx, capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result.Data(), []byte(`{"foo":"missing.example.com"}`)) {
+		t.Errorf("expected unmodified data, got %s", result.Data())
+	}
+
+	proc, err = newProcDNS(ctx, dnsConfig("query_mx", addr, map[string]interface{}{
+		"ignore_errors": true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := proc.Apply(ctx, capsule); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestDNSCacheKey(t *testing.T) {
+	a := procDNS{Options: procDNSOptions{Type: "query_mx", Resolver: "1.1.1.1"}}
+	b := procDNS{Options: procDNSOptions{Type: "query_mx", Resolver: "8.8.8.8"}}
+
+	if a.cacheKey("example.com") == b.cacheKey("example.com") {
+		t.Error("expected different keys for different resolvers")
+	}
+}
+
+func TestDNSCache(t *testing.T) {
+	ctx := context.TODO()
+	addr, count := dnsTestServer(t)
+
+	cfg := dnsConfig("query_mx", addr, nil)
+	cfg.Settings["options"].(map[string]interface{})["kv_options"] = map[string]interface{}{
+		"type": "memory",
+		"settings": map[string]interface{}{
+			"capacity": 101,
+		},
+	}
+
+	proc, err := newProcDNS(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	for i := 0; i < 3; i++ {
+		capsule := config.NewCapsule()
+		capsule.SetData([]byte(`{"foo":"example.com"}`))
+
+		result, err := proc.Apply(ctx, capsule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := []byte(`{"foo":"example.com","bar":[{"host":"mx.example.com.","preference":10,"ttl":300}]}`)
+		if !bytes.Equal(result.Data(), expected) {
+			t.Errorf("expected %s, got %s", expected, result.Data())
+		}
+	}
+
+	if c := atomic.LoadInt32(count); c != 1 {
+		t.Errorf("expected 1 query, got %d", c)
+	}
+}
+
+func benchmarkDNS(b *testing.B, applier procDNS, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkDNS(b *testing.B) {
+	addr, _ := dnsTestServer(b)
+	capsule := config.NewCapsule()
+	for _, test := range dnsTests {
+		proc, err := newProcDNS(context.TODO(), dnsConfig(test.typ, addr, nil))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkDNS(b, proc, capsule)
+			},
+		)
+	}
+}
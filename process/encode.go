@@ -0,0 +1,351 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"math/big"
+	"mime/quotedprintable"
+	"net/url"
+	"unicode/utf8"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// errEncodeDecodedBinary is returned when the encode processor is configured
+// to decode output into an object, but the output contains binary data and
+// cannot be written into a valid object.
+var errEncodeDecodedBinary = fmt.Errorf("cannot write binary as object")
+
+// errEncodeInvalidBase58 is returned when the encode processor decodes data
+// that contains characters that are not in the base58 alphabet.
+var errEncodeInvalidBase58 = fmt.Errorf("invalid base58 character")
+
+// encodeBase58Alphabet is the Bitcoin base58 alphabet.
+const encodeBase58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// encode processes data by encoding or decoding it. Text encodings (e.g.,
+// hex, URL) and character sets (e.g., UTF-16) are supported.
+//
+// This processor supports the data and object handling patterns.
+type procEncode struct {
+	process
+	Options procEncodeOptions `json:"options"`
+}
+
+type procEncodeOptions struct {
+	// Direction determines whether data is encoded or decoded.
+	//
+	// Must be one of:
+	//
+	// - to: encode data (for character sets, UTF-8 is converted to the
+	// character set)
+	//
+	// - from: decode data (for character sets, the character set is
+	// converted to UTF-8)
+	Direction string `json:"direction"`
+	// Type is the encoding type.
+	//
+	// Must be one of:
+	//
+	// - hex
+	//
+	// - base32
+	//
+	// - base58 (Bitcoin alphabet)
+	//
+	// - url_query: URL query escaping (spaces are encoded as "+")
+	//
+	// - url_path: URL path escaping (spaces are encoded as "%20")
+	//
+	// - html: HTML entities
+	//
+	// - quoted_printable
+	//
+	// - utf16le: UTF-16 (little endian)
+	//
+	// - utf16be: UTF-16 (big endian)
+	//
+	// - latin1: ISO 8859-1
+	Type string `json:"type"`
+	// Recursive determines if data is repeatedly decoded until it no
+	// longer changes, it cannot be decoded, or MaxDepth is reached. This is
+	// useful for data that is encoded multiple times (e.g., double URL
+	// encoding). Character sets (utf16le, utf16be, latin1) cannot be
+	// decoded recursively.
+	//
+	// This is optional and defaults to false.
+	Recursive bool `json:"recursive"`
+	// MaxDepth is the maximum number of times that data is decoded if
+	// Recursive is true.
+	//
+	// This is optional and defaults to 10.
+	MaxDepth int `json:"max_depth"`
+}
+
+// Create a new encode processor.
+func newProcEncode(ctx context.Context, cfg config.Config) (p procEncode, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procEncode{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procEncode{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procEncode{}, fmt.Errorf("process: encode: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"hex",
+			"base32",
+			"base58",
+			"url_query",
+			"url_path",
+			"html",
+			"quoted_printable",
+			"utf16le",
+			"utf16be",
+			"latin1",
+		},
+		p.Options.Type) {
+		return procEncode{}, fmt.Errorf("process: encode: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Recursive && p.Options.Direction != "from" {
+		return procEncode{}, fmt.Errorf("process: encode: recursive direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	// character sets always decode and never converge, so they cannot be
+	// decoded recursively
+	if p.Options.Recursive && slices.Contains(
+		[]string{
+			"utf16le",
+			"utf16be",
+			"latin1",
+		},
+		p.Options.Type) {
+		return procEncode{}, fmt.Errorf("process: encode: recursive type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	if p.Options.MaxDepth == 0 {
+		p.Options.MaxDepth = 10
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procEncode) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procEncode) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procEncode) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procEncode) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key).String()
+
+		value, err := p.encode([]byte(result))
+		if err != nil {
+			return capsule, fmt.Errorf("process: encode: %v", err)
+		}
+
+		if !utf8.Valid(value) {
+			return capsule, fmt.Errorf("process: encode: %v", errEncodeDecodedBinary)
+		}
+
+		if err := capsule.Set(p.SetKey, string(value)); err != nil {
+			return capsule, fmt.Errorf("process: encode: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, err := p.encode(capsule.Data())
+		if err != nil {
+			return capsule, fmt.Errorf("process: encode: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: encode: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// encode encodes or decodes data. If recursive decoding is enabled, then
+// errors that occur after the data is decoded once are ignored.
+func (p procEncode) encode(data []byte) ([]byte, error) {
+	if p.Options.Direction == "to" {
+		return p.to(data)
+	}
+
+	value, err := p.from(data)
+	if err != nil || !p.Options.Recursive {
+		return value, err
+	}
+
+	for i := 1; i < p.Options.MaxDepth; i++ {
+		next, err := p.from(value)
+		if err != nil || bytes.Equal(next, value) {
+			break
+		}
+
+		value = next
+	}
+
+	return value, nil
+}
+
+func (p procEncode) to(data []byte) ([]byte, error) {
+	switch p.Options.Type {
+	case "hex":
+		return []byte(hex.EncodeToString(data)), nil
+	case "base32":
+		return []byte(base32.StdEncoding.EncodeToString(data)), nil
+	case "base58":
+		return encodeBase58(data), nil
+	case "url_query":
+		return []byte(url.QueryEscape(string(data))), nil
+	case "url_path":
+		return []byte(url.PathEscape(string(data))), nil
+	case "html":
+		return []byte(html.EscapeString(string(data))), nil
+	case "quoted_printable":
+		var buf bytes.Buffer
+		w := quotedprintable.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	default:
+		return p.charset().NewEncoder().Bytes(data)
+	}
+}
+
+func (p procEncode) from(data []byte) ([]byte, error) {
+	switch p.Options.Type {
+	case "hex":
+		return hex.DecodeString(string(data))
+	case "base32":
+		return base32.StdEncoding.DecodeString(string(data))
+	case "base58":
+		return decodeBase58(data)
+	case "url_query":
+		s, err := url.QueryUnescape(string(data))
+		return []byte(s), err
+	case "url_path":
+		s, err := url.PathUnescape(string(data))
+		return []byte(s), err
+	case "html":
+		return []byte(html.UnescapeString(string(data))), nil
+	case "quoted_printable":
+		return io.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+	default:
+		return p.charset().NewDecoder().Bytes(data)
+	}
+}
+
+// charset returns the character set encoding for the type.
+func (p procEncode) charset() encoding.Encoding {
+	switch p.Options.Type {
+	case "utf16le":
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case "utf16be":
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	default:
+		return charmap.ISO8859_1
+	}
+}
+
+// encodeBase58 encodes bytes to base58. Leading zero bytes are encoded as
+// the first character of the alphabet.
+func encodeBase58(data []byte) []byte {
+	var zeros int
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, encodeBase58Alphabet[mod.Int64()])
+	}
+
+	for i := 0; i < zeros; i++ {
+		out = append(out, encodeBase58Alphabet[0])
+	}
+
+	// digits are calculated in reverse order
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+
+	return out
+}
+
+// decodeBase58 decodes base58 to bytes.
+func decodeBase58(data []byte) ([]byte, error) {
+	var zeros int
+	for zeros < len(data) && data[zeros] == encodeBase58Alphabet[0] {
+		zeros++
+	}
+
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range data {
+		i := bytes.IndexByte([]byte(encodeBase58Alphabet), c)
+		if i < 0 {
+			return nil, fmt.Errorf("%q: %v", c, errEncodeInvalidBase58)
+		}
+
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
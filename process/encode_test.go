@@ -0,0 +1,286 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procEncode{}
+	_ Batcher = procEncode{}
+)
+
+var encodeTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"hex to",
+		encodeConfig("", "to", "hex", nil),
+		[]byte(`foo`),
+		[]byte(`666f6f`),
+		nil,
+	},
+	{
+		"hex from",
+		encodeConfig("", "from", "hex", nil),
+		[]byte(`666f6f`),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"base32 to",
+		encodeConfig("", "to", "base32", nil),
+		[]byte(`foo`),
+		[]byte(`MZXW6===`),
+		nil,
+	},
+	{
+		"base32 from",
+		encodeConfig("", "from", "base32", nil),
+		[]byte(`MZXW6===`),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"base58 to",
+		encodeConfig("", "to", "base58", nil),
+		[]byte("\x00\x00hello world"),
+		[]byte(`11StV1DL6CwTryKyV`),
+		nil,
+	},
+	{
+		"base58 from",
+		encodeConfig("", "from", "base58", nil),
+		[]byte(`11StV1DL6CwTryKyV`),
+		[]byte("\x00\x00hello world"),
+		nil,
+	},
+	{
+		"url_query to",
+		encodeConfig("", "to", "url_query", nil),
+		[]byte(`a b&c=d/e`),
+		[]byte(`a+b%26c%3Dd%2Fe`),
+		nil,
+	},
+	{
+		"url_path to",
+		encodeConfig("", "to", "url_path", nil),
+		[]byte(`a b&c=d/e`),
+		[]byte(`a%20b&c=d%2Fe`),
+		nil,
+	},
+	{
+		"url_path from",
+		encodeConfig("", "from", "url_path", nil),
+		[]byte(`a%20b+c`),
+		[]byte(`a b+c`),
+		nil,
+	},
+	{
+		"html to",
+		encodeConfig("", "to", "html", nil),
+		[]byte(`<a href="foo">bar</a>`),
+		[]byte(`&lt;a href=&#34;foo&#34;&gt;bar&lt;/a&gt;`),
+		nil,
+	},
+	{
+		"html from",
+		encodeConfig("", "from", "html", nil),
+		[]byte(`&lt;b&gt;caf&eacute;&lt;/b&gt;`),
+		[]byte(`<b>café</b>`),
+		nil,
+	},
+	{
+		"quoted_printable to",
+		encodeConfig("", "to", "quoted_printable", nil),
+		[]byte(`café=`),
+		[]byte(`caf=C3=A9=3D`),
+		nil,
+	},
+	{
+		"quoted_printable from",
+		encodeConfig("", "from", "quoted_printable", nil),
+		[]byte(`caf=C3=A9=3D`),
+		[]byte(`café=`),
+		nil,
+	},
+	{
+		"utf16le to",
+		encodeConfig("", "to", "utf16le", nil),
+		[]byte(`foo`),
+		[]byte("f\x00o\x00o\x00"),
+		nil,
+	},
+	{
+		"utf16be from",
+		encodeConfig("", "from", "utf16be", nil),
+		[]byte("\x00f\x00o\x00o"),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"latin1 to",
+		encodeConfig("", "to", "latin1", nil),
+		[]byte(`café`),
+		[]byte("caf\xe9"),
+		nil,
+	},
+	{
+		"latin1 from",
+		encodeConfig("", "from", "latin1", nil),
+		[]byte("caf\xe9"),
+		[]byte(`café`),
+		nil,
+	},
+	{
+		"recursive",
+		encodeConfig("", "from", "url_query", map[string]interface{}{
+			"recursive": true,
+		}),
+		[]byte(`foo%25253Cbar%25253E`),
+		[]byte(`foo<bar>`),
+		nil,
+	},
+	{
+		"recursive max_depth",
+		encodeConfig("", "from", "url_query", map[string]interface{}{
+			"recursive": true,
+			"max_depth": 2,
+		}),
+		[]byte(`foo%25253Cbar%25253E`),
+		[]byte(`foo%3Cbar%3E`),
+		nil,
+	},
+	{
+		"recursive stops on error",
+		encodeConfig("", "from", "hex", map[string]interface{}{
+			"recursive": true,
+		}),
+		[]byte(`363636663666`),
+		[]byte(`foo`),
+		nil,
+	},
+	{
+		"JSON",
+		encodeConfig("foo", "from", "url_query", nil),
+		[]byte(`{"foo":"a%20b"}`),
+		[]byte(`{"foo":"a b"}`),
+		nil,
+	},
+}
+
+func encodeConfig(key, direction, typ string, options map[string]interface{}) config.Config {
+	opts := map[string]interface{}{
+		"direction": direction,
+		"type":      typ,
+	}
+	for k, v := range options {
+		opts[k] = v
+	}
+
+	settings := map[string]interface{}{
+		"options": opts,
+	}
+	if key != "" {
+		settings["key"] = key
+		settings["set_key"] = key
+	}
+
+	return config.Config{
+		Type:     "encode",
+		Settings: settings,
+	}
+}
+
+func TestEncode(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range encodeTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcEncode(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, cfg := range []config.Config{
+		encodeConfig("", "from", "base64", nil),
+		encodeConfig("", "to", "hex", map[string]interface{}{"recursive": true}),
+		encodeConfig("", "from", "latin1", map[string]interface{}{"recursive": true}),
+		encodeConfig("", "from", "utf16le", map[string]interface{}{"recursive": true}),
+		encodeConfig("", "from", "utf16be", map[string]interface{}{"recursive": true}),
+	} {
+		if _, err := newProcEncode(ctx, cfg); err == nil {
+			t.Errorf("expected error for config %v", cfg.Settings)
+		}
+	}
+
+	for _, test := range []struct {
+		cfg  config.Config
+		data []byte
+	}{
+		{encodeConfig("", "from", "hex", nil), []byte(`zz`)},
+		{encodeConfig("", "from", "base58", nil), []byte(`0OIl`)},
+		{encodeConfig("foo", "from", "hex", nil), []byte(`{"foo":"ff"}`)},
+	} {
+		proc, err := newProcEncode(ctx, test.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		capsule := config.NewCapsule()
+		capsule.SetData(test.data)
+
+		if _, err := proc.Apply(ctx, capsule); err == nil {
+			t.Errorf("expected error for data %s", test.data)
+		}
+	}
+}
+
+func benchmarkEncode(b *testing.B, applier procEncode, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range encodeTests {
+		proc, err := newProcEncode(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkEncode(b, proc, capsule)
+			},
+		)
+	}
+}
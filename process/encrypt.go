@@ -0,0 +1,462 @@
+//go:build !wasm
+
+package process
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/aws/kms"
+	"github.com/brexhq/substation/internal/base64"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
+	"github.com/brexhq/substation/internal/secrets"
+)
+
+var kmsAPI kms.API
+
+// errEncryptKeyNotFound is returned when the encrypt processor cannot find
+// the key that is required to encrypt or decrypt data.
+var errEncryptKeyNotFound = fmt.Errorf("key not found")
+
+// errEncryptInvalidKey is returned when the encrypt processor is configured
+// with a key that is not a base64 encoded 256-bit key.
+var errEncryptInvalidKey = fmt.Errorf("key must be 256 bits")
+
+// errEncryptInvalidCiphertext is returned when the encrypt processor cannot
+// parse the header of encrypted data.
+var errEncryptInvalidCiphertext = fmt.Errorf("invalid ciphertext")
+
+const (
+	// encryptVersion is the version of the ciphertext format.
+	encryptVersion = 1
+	// encryptModeKey indicates that data was encrypted with a key from
+	// the processor configuration.
+	encryptModeKey = 0
+	// encryptModeKMS indicates that data was encrypted with a data key
+	// that is wrapped by an AWS KMS key.
+	encryptModeKMS = 1
+)
+
+// encrypt processes data by encrypting and decrypting it with AES-256-GCM.
+//
+// Encrypted data is self-describing and contains all information, except
+// for the key, that is required for decryption:
+//
+// - version (1 byte)
+//
+// - mode (1 byte; 0 for keys in the configuration, 1 for KMS)
+//
+// - key ID length (1 byte) and key ID
+//
+// - wrapped data key length (2 bytes) and wrapped data key (KMS mode only)
+//
+// - nonce (12 bytes)
+//
+// - sealed data and authentication tag
+//
+// The header (everything before the nonce) is authenticated as additional
+// data, so any changes to it cause decryption to fail. Because the key ID is
+// stored with the data, keys can be rotated by adding a new key to the
+// configuration and changing the key ID that is used for encryption; old keys
+// must be kept until all data encrypted by them is decrypted.
+//
+// If an AWS KMS key is configured, then the processor uses envelope
+// encryption: a data key is generated by KMS when the processor is created,
+// data is encrypted with the data key, and the data key (encrypted by KMS)
+// is stored with the data. Data keys are decrypted by KMS and cached in memory
+// during decryption.
+//
+// When handling objects, encrypted data is stored in the object as base64
+// encoded strings.
+//
+// This processor supports the data and object handling patterns.
+type procEncrypt struct {
+	process
+	Options procEncryptOptions `json:"options"`
+
+	// dataKey and wrappedKey are only used for encryption with KMS.
+	dataKey    []byte
+	wrappedKey []byte
+	// cache stores data keys decrypted by KMS.
+	cache kv.Storer
+}
+
+type procEncryptOptions struct {
+	// Direction determines whether data is encrypted or decrypted.
+	//
+	// Must be one of:
+	//
+	// - to: encrypt data
+	//
+	// - from: decrypt data
+	Direction string `json:"direction"`
+	// KeyID is the identifier of the key in Keys that is used to
+	// encrypt data.
+	//
+	// This is required when encrypting data without KMS.
+	KeyID string `json:"key_id"`
+	// Keys maps key identifiers to base64 encoded 256-bit keys. Keys
+	// can be retrieved from secrets (e.g., ${SECRETS_ENV:ENCRYPT_KEY}).
+	//
+	// This is optional if all data is encrypted with KMS.
+	Keys map[string]string `json:"keys"`
+	// KMSKeyID is the AWS KMS key (ID, ARN, or alias) that is used to
+	// generate data keys. If this is set, then KeyID and Keys are not
+	// used for encryption.
+	//
+	// This is optional and only used when encrypting data.
+	KMSKeyID string `json:"kms_key_id"`
+}
+
+// Create a new encrypt processor.
+func newProcEncrypt(ctx context.Context, cfg config.Config) (p procEncrypt, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procEncrypt{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procEncrypt{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procEncrypt{}, fmt.Errorf("process: encrypt: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procEncrypt{}, fmt.Errorf("process: encrypt: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	// key IDs are stored in a single byte
+	for id := range p.Options.Keys {
+		if len(id) == 0 || len(id) > 255 {
+			return procEncrypt{}, fmt.Errorf("process: encrypt: key_id %q: %v", id, errors.ErrInvalidOption)
+		}
+	}
+
+	if len(p.Options.KMSKeyID) > 255 {
+		return procEncrypt{}, fmt.Errorf("process: encrypt: kms_key_id %q: %v", p.Options.KMSKeyID, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Direction == "to" {
+		if p.Options.KMSKeyID != "" {
+			// lazy load API
+			if !kmsAPI.IsEnabled() {
+				kmsAPI.Setup()
+			}
+
+			p.dataKey, p.wrappedKey, err = kmsAPI.GenerateDataKey(ctx, p.Options.KMSKeyID)
+			if err != nil {
+				return procEncrypt{}, fmt.Errorf("process: encrypt: %v", err)
+			}
+
+			return p, nil
+		}
+
+		if p.Options.KeyID == "" {
+			return procEncrypt{}, fmt.Errorf("process: encrypt: key_id: %v", errors.ErrMissingRequiredOption)
+		}
+
+		if _, ok := p.Options.Keys[p.Options.KeyID]; !ok {
+			return procEncrypt{}, fmt.Errorf("process: encrypt: key_id %s: %v", p.Options.KeyID, errEncryptKeyNotFound)
+		}
+
+		return p, nil
+	}
+
+	p.cache, err = kv.New(config.Config{
+		Type: "memory",
+		Settings: map[string]interface{}{
+			"capacity": 128,
+		},
+	})
+	if err != nil {
+		return procEncrypt{}, fmt.Errorf("process: encrypt: %v", err)
+	}
+
+	if err := p.cache.Setup(ctx); err != nil {
+		return procEncrypt{}, fmt.Errorf("process: encrypt: %v", err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procEncrypt) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procEncrypt) Close(context.Context) error {
+	if p.IgnoreClose || p.cache == nil {
+		return nil
+	}
+
+	if err := p.cache.Close(); err != nil {
+		return fmt.Errorf("close: encrypt: %v", err)
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procEncrypt) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procEncrypt) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key).String()
+
+		switch p.Options.Direction {
+		case "from":
+			b, err := base64.Decode([]byte(result))
+			if err != nil {
+				return capsule, fmt.Errorf("process: encrypt: %v", err)
+			}
+
+			value, err := p.decrypt(ctx, b)
+			if err != nil {
+				return capsule, fmt.Errorf("process: encrypt: %v", err)
+			}
+
+			if err := capsule.Set(p.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: encrypt: %v", err)
+			}
+		case "to":
+			value, err := p.encrypt(ctx, []byte(result))
+			if err != nil {
+				return capsule, fmt.Errorf("process: encrypt: %v", err)
+			}
+
+			if err := capsule.Set(p.SetKey, string(base64.Encode(value))); err != nil {
+				return capsule, fmt.Errorf("process: encrypt: %v", err)
+			}
+		default:
+			return capsule, fmt.Errorf("process: encrypt: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		var value []byte
+		var err error
+
+		switch p.Options.Direction {
+		case "from":
+			value, err = p.decrypt(ctx, capsule.Data())
+		case "to":
+			value, err = p.encrypt(ctx, capsule.Data())
+		default:
+			return capsule, fmt.Errorf("process: encrypt: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		if err != nil {
+			return capsule, fmt.Errorf("process: encrypt: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: encrypt: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// key returns the key from the configuration that matches the key ID.
+// Secrets are interpolated every time the key is retrieved so that they
+// can be rotated without restarting the application.
+func (p procEncrypt) key(ctx context.Context, id string) ([]byte, error) {
+	v, ok := p.Options.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key_id %s: %v", id, errEncryptKeyNotFound)
+	}
+
+	s, err := secrets.Interpolate(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.Decode([]byte(s))
+	if err != nil {
+		return nil, fmt.Errorf("key_id %s: %v", id, err)
+	}
+
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key_id %s: %v", id, errEncryptInvalidKey)
+	}
+
+	return key, nil
+}
+
+// encrypt returns encrypted data prefixed with a header that describes the key.
+func (p procEncrypt) encrypt(ctx context.Context, data []byte) ([]byte, error) {
+	var header bytes.Buffer
+	var key []byte
+
+	header.WriteByte(encryptVersion)
+	if p.Options.KMSKeyID != "" {
+		header.WriteByte(encryptModeKMS)
+		header.WriteByte(byte(len(p.Options.KMSKeyID)))
+		header.WriteString(p.Options.KMSKeyID)
+
+		l := make([]byte, 2)
+		binary.BigEndian.PutUint16(l, uint16(len(p.wrappedKey)))
+		header.Write(l)
+		header.Write(p.wrappedKey)
+
+		key = p.dataKey
+	} else {
+		header.WriteByte(encryptModeKey)
+		header.WriteByte(byte(len(p.Options.KeyID)))
+		header.WriteString(p.Options.KeyID)
+
+		var err error
+		key, err = p.key(ctx, p.Options.KeyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	aead, err := encryptAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, header.Len()+len(nonce)+len(data)+aead.Overhead())
+	out = append(out, header.Bytes()...)
+	out = append(out, nonce...)
+
+	return aead.Seal(out, nonce, data, header.Bytes()), nil
+}
+
+// decrypt parses the header of encrypted data and returns the decrypted data.
+func (p procEncrypt) decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	if len(data) < 3 || data[0] != encryptVersion {
+		return nil, errEncryptInvalidCiphertext
+	}
+
+	mode := data[1]
+	idLen := int(data[2])
+	pos := 3
+	if len(data) < pos+idLen {
+		return nil, errEncryptInvalidCiphertext
+	}
+
+	id := string(data[pos : pos+idLen])
+	pos += idLen
+
+	var key []byte
+	var err error
+
+	switch mode {
+	case encryptModeKey:
+		key, err = p.key(ctx, id)
+	case encryptModeKMS:
+		if len(data) < pos+2 {
+			return nil, errEncryptInvalidCiphertext
+		}
+
+		wrappedLen := int(binary.BigEndian.Uint16(data[pos : pos+2]))
+		pos += 2
+		if len(data) < pos+wrappedLen {
+			return nil, errEncryptInvalidCiphertext
+		}
+
+		key, err = p.unwrap(ctx, id, data[pos:pos+wrappedLen])
+		pos += wrappedLen
+	default:
+		return nil, errEncryptInvalidCiphertext
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := encryptAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < pos+aead.NonceSize() {
+		return nil, errEncryptInvalidCiphertext
+	}
+
+	header := data[:pos]
+	nonce := data[pos : pos+aead.NonceSize()]
+
+	return aead.Open(nil, nonce, data[pos+aead.NonceSize():], header)
+}
+
+// unwrap returns a data key that was encrypted by KMS. Data keys are
+// retrieved from the cache if they exist, otherwise they are decrypted by KMS
+// and put into the cache.
+func (p procEncrypt) unwrap(ctx context.Context, id string, wrapped []byte) ([]byte, error) {
+	cacheKey := string(wrapped)
+	cached, err := p.cache.Get(ctx, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := cached.([]byte); ok {
+		return key, nil
+	}
+
+	// lazy load API
+	if !kmsAPI.IsEnabled() {
+		kmsAPI.Setup()
+	}
+
+	key, err := kmsAPI.Decrypt(ctx, id, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.cache.Set(ctx, cacheKey, key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// encryptAEAD returns an AES-GCM cipher for a 256-bit key.
+func encryptAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, errEncryptInvalidKey
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,305 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procEncrypt{}
+	_ Batcher = procEncrypt{}
+)
+
+// base64 encoded "0123456789abcdef0123456789abcdef"
+const encryptTestKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+var encryptTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON from",
+		config.Config{
+			Type: "encrypt",
+			Settings: map[string]interface{}{
+				"key":     "foo",
+				"set_key": "foo",
+				"options": map[string]interface{}{
+					"direction": "from",
+					"keys": map[string]interface{}{
+						"v1": encryptTestKey,
+					},
+				},
+			},
+		},
+		[]byte(`{"foo":"AQACdjHjwlbx9OqsOZfu/47IkCa2FWzcP/6jnXuCLpy8R0dvcm2wB0HxKD449NYS"}`),
+		[]byte(`{"foo":"foo@example.com"}`),
+		nil,
+	},
+	{
+		"JSON from unknown key",
+		config.Config{
+			Type: "encrypt",
+			Settings: map[string]interface{}{
+				"key":     "foo",
+				"set_key": "foo",
+				"options": map[string]interface{}{
+					"direction": "from",
+					"keys": map[string]interface{}{
+						"v2": encryptTestKey,
+					},
+				},
+			},
+		},
+		[]byte(`{"foo":"AQACdjHjwlbx9OqsOZfu/47IkCa2FWzcP/6jnXuCLpy8R0dvcm2wB0HxKD449NYS"}`),
+		[]byte(`{"foo":"AQACdjHjwlbx9OqsOZfu/47IkCa2FWzcP/6jnXuCLpy8R0dvcm2wB0HxKD449NYS"}`),
+		errEncryptKeyNotFound,
+	},
+	{
+		"JSON from secret",
+		config.Config{
+			Type: "encrypt",
+			Settings: map[string]interface{}{
+				"key":     "foo",
+				"set_key": "foo",
+				"options": map[string]interface{}{
+					"direction": "from",
+					"keys": map[string]interface{}{
+						"v1": "${SECRETS_ENV:SUBSTATION_ENCRYPT_TEST_KEY}",
+					},
+				},
+			},
+		},
+		[]byte(`{"foo":"AQACdjHjwlbx9OqsOZfu/47IkCa2FWzcP/6jnXuCLpy8R0dvcm2wB0HxKD449NYS"}`),
+		[]byte(`{"foo":"foo@example.com"}`),
+		nil,
+	},
+	{
+		"data from",
+		config.Config{
+			Type: "encrypt",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"keys": map[string]interface{}{
+						"v1": encryptTestKey,
+					},
+				},
+			},
+		},
+		[]byte("\x01\x00\x02v1\xe3\xc2V\xf1\xf4\xea\xac9\x97\xee\xff\x8e\xc8\x90&\xb6\x15l\xdc?\xfe\xa3\x9d{\x82.\x9c\xbcGGorm\xb0\aA\xf1(>8\xf4\xd6\x12"),
+		[]byte(`foo@example.com`),
+		nil,
+	},
+}
+
+func TestEncrypt(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	t.Setenv("SUBSTATION_ENCRYPT_TEST_KEY", encryptTestKey)
+
+	for _, test := range encryptTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcEncrypt(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if test.err != nil {
+				if err == nil {
+					t.Errorf("expected error %v, got nil", test.err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+// Encryption uses a random nonce, so it is tested by encrypting and
+// decrypting the same data.
+func TestEncryptRotation(t *testing.T) {
+	ctx := context.TODO()
+
+	to, err := newProcEncrypt(ctx, config.Config{
+		Type: "encrypt",
+		Settings: map[string]interface{}{
+			"key":     "foo",
+			"set_key": "foo",
+			"options": map[string]interface{}{
+				"direction": "to",
+				"key_id":    "v2",
+				"keys": map[string]interface{}{
+					"v1": encryptTestKey,
+					"v2": "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := newProcEncrypt(ctx, config.Config{
+		Type: "encrypt",
+		Settings: map[string]interface{}{
+			"key":     "foo",
+			"set_key": "foo",
+			"options": map[string]interface{}{
+				"direction": "from",
+				"keys": map[string]interface{}{
+					"v1": encryptTestKey,
+					"v2": "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [][]byte{
+		[]byte(`{"foo":"foo@example.com"}`),
+		[]byte(`{"foo":{"bar":"baz"}}`),
+	}
+
+	for _, test := range tests {
+		encrypted, err := ApplyBytes(ctx, test, to)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Equal(encrypted, test) {
+			t.Errorf("expected encrypted data, got %s", encrypted)
+		}
+
+		result, err := ApplyBytes(ctx, encrypted, from)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(result, test) {
+			t.Errorf("expected %s, got %s", test, result)
+		}
+	}
+}
+
+type mockedKMS struct {
+	kmsiface.KMSAPI
+}
+
+func (m mockedKMS) GenerateDataKeyWithContext(ctx aws.Context, input *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	return &kms.GenerateDataKeyOutput{
+		Plaintext:      []byte("0123456789abcdef0123456789abcdef"),
+		CiphertextBlob: []byte("wrapped"),
+	}, nil
+}
+
+func (m mockedKMS) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	return &kms.DecryptOutput{
+		Plaintext: []byte("0123456789abcdef0123456789abcdef"),
+	}, nil
+}
+
+func TestEncryptKMS(t *testing.T) {
+	ctx := context.TODO()
+
+	kmsAPI.Client = mockedKMS{}
+	defer func() { kmsAPI.Client = nil }()
+
+	to, err := newProcEncrypt(ctx, config.Config{
+		Type: "encrypt",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"direction":  "to",
+				"kms_key_id": "alias/substation",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// decryption does not require any keys because the wrapped
+	// data key is stored with the encrypted data
+	from, err := newProcEncrypt(ctx, config.Config{
+		Type: "encrypt",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"direction": "from",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := []byte(`foo@example.com`)
+
+	encrypted, err := ApplyBytes(ctx, test, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(encrypted, []byte("\x01\x01\x10alias/substation\x00\x07wrapped")) {
+		t.Errorf("unexpected header %q", encrypted)
+	}
+
+	result, err := ApplyBytes(ctx, encrypted, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result, test) {
+		t.Errorf("expected %s, got %s", test, result)
+	}
+}
+
+func benchmarkEncrypt(b *testing.B, applier procEncrypt, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkEncrypt(b *testing.B) {
+	capsule := config.NewCapsule()
+	b.Setenv("SUBSTATION_ENCRYPT_TEST_KEY", encryptTestKey)
+
+	for _, test := range encryptTests {
+		proc, err := newProcEncrypt(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkEncrypt(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procEncrypt struct {
+	process
+	Options procEncryptOptions `json:"options"`
+}
+
+type procEncryptOptions struct{}
+
+func newProcEncrypt(ctx context.Context, cfg config.Config) (p procEncrypt, err error) {
+	return procEncrypt{}, fmt.Errorf("process: encrypt: %v", syscall.ENOSYS)
+}
+
+func (p procEncrypt) String() string {
+	return toString(p)
+}
+
+func (p procEncrypt) Close(ctx context.Context) error {
+	return fmt.Errorf("close: encrypt: %v", syscall.ENOSYS)
+}
+
+func (p procEncrypt) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procEncrypt) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: encrypt: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,151 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// errorHandler processes data by applying a series of processors and
+// handling any error that occurs. If any processor fails, then the data is
+// rolled back to its original state, the error is written to SetKey, and
+// optional fallback processors are applied. This is useful for routing
+// failed data with conditions in downstream processors or sinks instead of
+// failing the entire batch.
+//
+// The error is written as an object that contains the error message and the
+// type of the processor that failed:
+//
+//	{"message":"process: http: ...","processor":"http"}
+//
+// This processor supports the data and object handling patterns. If SetKey
+// is not set, then the error is written to metadata in the "error" key.
+type procErrorHandler struct {
+	process
+	Options procErrorHandlerOptions `json:"options"`
+
+	appliers []Applier
+	fallback []Applier
+}
+
+type procErrorHandlerOptions struct {
+	// Processors applied in series to the data.
+	Processors []config.Config `json:"processors"`
+	// Fallback processors are applied in series to the original data if any
+	// processor fails. Errors from these processors are not handled.
+	//
+	// This is optional and defaults to no fallback.
+	Fallback []config.Config `json:"fallback"`
+}
+
+// errorHandlerRecord is the error written by the error handler processor.
+type errorHandlerRecord struct {
+	Message   string `json:"message"`
+	Processor string `json:"processor"`
+}
+
+// Create a new error handler processor.
+func newProcErrorHandler(ctx context.Context, cfg config.Config) (p procErrorHandler, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procErrorHandler{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procErrorHandler{}, err
+	}
+
+	// error early if required options are missing
+	if len(p.Options.Processors) == 0 {
+		return procErrorHandler{}, fmt.Errorf("process: error_handler: processors: %v", errors.ErrMissingRequiredOption)
+	}
+
+	if p.SetKey == "" {
+		p.SetKey = "!metadata error"
+	}
+
+	p.appliers, err = NewAppliers(ctx, p.Options.Processors...)
+	if err != nil {
+		return procErrorHandler{}, fmt.Errorf("process: error_handler: processors %+v: %v", p.Options.Processors, err)
+	}
+
+	p.fallback, err = NewAppliers(ctx, p.Options.Fallback...)
+	if err != nil {
+		return procErrorHandler{}, fmt.Errorf("process: error_handler: fallback %+v: %v", p.Options.Fallback, err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procErrorHandler) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procErrorHandler) Close(ctx context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if err := CloseAppliers(ctx, p.appliers...); err != nil {
+		return fmt.Errorf("close: error_handler: %v", err)
+	}
+
+	if err := CloseAppliers(ctx, p.fallback...); err != nil {
+		return fmt.Errorf("close: error_handler: %v", err)
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procErrorHandler) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procErrorHandler) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// the original data and metadata are copied in case any processor
+	// modifies them in place before failing.
+	original := config.NewCapsule()
+	original.SetData(append([]byte(nil), capsule.Data()...))
+	if meta := capsule.Metadata(); meta != nil {
+		if _, err := original.SetMetadata(json.RawMessage(append([]byte(nil), meta...))); err != nil {
+			return capsule, fmt.Errorf("process: error_handler: %v", err)
+		}
+	}
+
+	newCapsule := capsule
+	for i, app := range p.appliers {
+		var err error
+		newCapsule, err = app.Apply(ctx, newCapsule)
+		if err == nil {
+			continue
+		}
+
+		// rollback
+		record := errorHandlerRecord{
+			Message:   err.Error(),
+			Processor: p.Options.Processors[i].Type,
+		}
+
+		if err := original.Set(p.SetKey, record); err != nil {
+			return capsule, fmt.Errorf("process: error_handler: %v", err)
+		}
+
+		original, err = Apply(ctx, original, p.fallback...)
+		if err != nil {
+			return capsule, fmt.Errorf("process: error_handler: fallback: %v", err)
+		}
+
+		return original, nil
+	}
+
+	return newCapsule, nil
+}
@@ -0,0 +1,220 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procErrorHandler{}
+	_ Batcher = procErrorHandler{}
+)
+
+var errorHandlerTests = []struct {
+	name      string
+	cfg       config.Config
+	test      []byte
+	expected  []byte
+	processor string
+}{
+	{
+		"success",
+		config.Config{
+			Type: "error_handler",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"processors": []config.Config{
+						{
+							Type: "copy",
+							Settings: map[string]interface{}{
+								"key":     "a",
+								"set_key": "b",
+							},
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"a":"Zm9v"}`),
+		[]byte(`{"a":"Zm9v","b":"Zm9v"}`),
+		"",
+	},
+	{
+		"rollback",
+		config.Config{
+			Type: "error_handler",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"processors": []config.Config{
+						{
+							Type: "copy",
+							Settings: map[string]interface{}{
+								"key":     "a",
+								"set_key": "b",
+							},
+						},
+						{
+							Type: "base64",
+							Settings: map[string]interface{}{
+								"key":     "a",
+								"set_key": "c",
+								"options": map[string]interface{}{
+									"direction": "from",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"a":"!!!"}`),
+		[]byte(`{"a":"!!!"}`),
+		"base64",
+	},
+	{
+		"fallback",
+		config.Config{
+			Type: "error_handler",
+			Settings: map[string]interface{}{
+				"set_key": "error",
+				"options": map[string]interface{}{
+					"processors": []config.Config{
+						{
+							Type: "base64",
+							Settings: map[string]interface{}{
+								"key":     "a",
+								"set_key": "a",
+								"options": map[string]interface{}{
+									"direction": "from",
+								},
+							},
+						},
+					},
+					"fallback": []config.Config{
+						{
+							Type: "insert",
+							Settings: map[string]interface{}{
+								"set_key": "d",
+								"options": map[string]interface{}{
+									"value": "fallback",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"a":"!!!"}`),
+		[]byte(`{"a":"!!!","d":"fallback"}`),
+		"base64",
+	},
+}
+
+func TestErrorHandler(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range errorHandlerTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule := config.NewCapsule()
+			capsule.SetData(test.test)
+
+			proc, err := newProcErrorHandler(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			processor := result.Get(proc.SetKey + ".processor").String()
+			if processor != test.processor {
+				t.Errorf("expected processor %s, got %s", test.processor, processor)
+			}
+
+			if test.processor != "" {
+				if !result.Get(proc.SetKey + ".message").Exists() {
+					t.Errorf("expected error message in %s", proc.SetKey)
+				}
+
+				if err := result.Delete(proc.SetKey); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestErrorHandlerFallbackError(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcErrorHandler(ctx, config.Config{
+		Type: "error_handler",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"processors": []config.Config{
+					{
+						Type: "base64",
+						Settings: map[string]interface{}{
+							"options": map[string]interface{}{
+								"direction": "from",
+							},
+						},
+					},
+				},
+				"fallback": []config.Config{
+					{
+						Type: "base64",
+						Settings: map[string]interface{}{
+							"options": map[string]interface{}{
+								"direction": "from",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`!!!`))
+
+	if _, err := proc.Apply(ctx, capsule); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func benchmarkErrorHandler(b *testing.B, applier procErrorHandler, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkErrorHandler(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range errorHandlerTests {
+		proc, err := newProcErrorHandler(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkErrorHandler(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,111 @@
+package process
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/expr"
+)
+
+// expr processes data by evaluating an expression (https://expr-lang.org/).
+// Expressions reference values in an object by their key and support
+// arithmetic (e.g., bytes_in + bytes_out), ternaries (e.g., status >= 400 ?
+// "error" : "ok"), and string and array functions (e.g., upper(user),
+// min(a, b)). The expr inspector in the condition package uses the same
+// language.
+//
+// Values that do not exist are nil, and expressions that return nil do not
+// modify the object. If set_key is not provided, then the processed data is
+// the result of the expression.
+//
+// This processor supports the data and object handling patterns.
+type procExpr struct {
+	process
+	Options procExprOptions `json:"options"`
+
+	program *expr.Program
+}
+
+type procExprOptions struct {
+	// Expression is the expression that is evaluated for each object.
+	Expression string `json:"expression"`
+}
+
+// Create a new expr processor.
+func newProcExpr(ctx context.Context, cfg config.Config) (p procExpr, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procExpr{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procExpr{}, err
+	}
+
+	// error early if required options are missing
+	if p.Options.Expression == "" {
+		return procExpr{}, fmt.Errorf("process: expr: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	p.program, err = expr.Compile(p.Options.Expression)
+	if err != nil {
+		return procExpr{}, fmt.Errorf("process: expr: %v", err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procExpr) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procExpr) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procExpr) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procExpr) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	value, err := p.program.Eval(capsule)
+	if err != nil {
+		return capsule, fmt.Errorf("process: expr: %v", err)
+	}
+
+	if value == nil {
+		return capsule, nil
+	}
+
+	// json processing
+	if p.SetKey != "" {
+		if err := capsule.Set(p.SetKey, value); err != nil {
+			return capsule, fmt.Errorf("process: expr: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if s, ok := value.(string); ok {
+		capsule.SetData([]byte(s))
+		return capsule, nil
+	}
+
+	b, err := gojson.Marshal(value)
+	if err != nil {
+		return capsule, fmt.Errorf("process: expr: %v", err)
+	}
+
+	capsule.SetData(b)
+	return capsule, nil
+}
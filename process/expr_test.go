@@ -0,0 +1,161 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procExpr{}
+	_ Batcher = procExpr{}
+)
+
+var exprTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"arithmetic",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"set_key": "bytes",
+				"options": map[string]interface{}{
+					"expression": "bytes_in + bytes_out",
+				},
+			},
+		},
+		[]byte(`{"bytes_in":10,"bytes_out":20}`),
+		[]byte(`{"bytes_in":10,"bytes_out":20,"bytes":30}`),
+		nil,
+	},
+	{
+		"division",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"set_key": "duration",
+				"options": map[string]interface{}{
+					"expression": "duration_ms / 1000",
+				},
+			},
+		},
+		[]byte(`{"duration_ms":1500}`),
+		[]byte(`{"duration_ms":1500,"duration":1.5}`),
+		nil,
+	},
+	{
+		"ternary",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"set_key": "outcome",
+				"options": map[string]interface{}{
+					"expression": `status >= 400 ? "failure" : "success"`,
+				},
+			},
+		},
+		[]byte(`{"status":200}`),
+		[]byte(`{"status":200,"outcome":"success"}`),
+		nil,
+	},
+	{
+		"array",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"set_key": "prod",
+				"options": map[string]interface{}{
+					"expression": `filter(hosts, {# startsWith "prod"})`,
+				},
+			},
+		},
+		[]byte(`{"hosts":["prod-1","dev-1","prod-2"]}`),
+		[]byte(`{"hosts":["prod-1","dev-1","prod-2"],"prod":["prod-1","prod-2"]}`),
+		nil,
+	},
+	{
+		"nil",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"set_key": "bar",
+				"options": map[string]interface{}{
+					"expression": "foo?.bar",
+				},
+			},
+		},
+		[]byte(`{"baz":1}`),
+		[]byte(`{"baz":1}`),
+		nil,
+	},
+	{
+		"data",
+		config.Config{
+			Type: "expr",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"expression": `user + "@" + domain`,
+				},
+			},
+		},
+		[]byte(`{"user":"foo","domain":"example.com"}`),
+		[]byte(`foo@example.com`),
+		nil,
+	},
+}
+
+func TestExpr(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range exprTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcExpr(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkExpr(b *testing.B, applier procExpr, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkExpr(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range exprTests {
+		proc, err := newProcExpr(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkExpr(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -5,16 +5,25 @@ package process
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	gojson "encoding/json"
 	"fmt"
 	"io"
 	gohttp "net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/time/rate"
 
 	"github.com/brexhq/substation/condition"
 	"github.com/brexhq/substation/config"
 	"github.com/brexhq/substation/internal/errors"
 	"github.com/brexhq/substation/internal/http"
+	"github.com/brexhq/substation/internal/json"
+	"github.com/brexhq/substation/internal/kv"
 	"github.com/brexhq/substation/internal/secrets"
 )
 
@@ -23,6 +32,10 @@ const httpInterp = `${data}`
 
 var httpClient http.HTTP
 
+// httpStatusPattern matches HTTP status codes (e.g., 404) and classes of
+// codes (e.g., 4xx).
+var httpStatusPattern = regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+
 // http processes data by retrieving a payload from an HTTP(S) URL. The HTTP client
 // used by the processor uses an exponential retry strategy that makes up to four requests
 // and does not wait more than 30 seconds for each retry, which may have significant impact
@@ -36,6 +49,9 @@ type procHTTP struct {
 	Options procHTTPOptions `json:"options"`
 
 	headers []http.Header
+	body    *procTemplate
+	kvStore kv.Storer
+	limiter *rate.Limiter
 }
 
 type procHTTPOptions struct {
@@ -47,7 +63,11 @@ type procHTTPOptions struct {
 	//
 	// - POST
 	//
-	// Defaults to GET.
+	// - PUT
+	//
+	// - PATCH
+	//
+	// Methods are case-insensitive and default to GET.
 	Method string `json:"method"`
 	// URL is the HTTP(S) endpoint that data is retrieved from.
 	//
@@ -68,10 +88,72 @@ type procHTTPOptions struct {
 	//
 	// This is optional and has no default.
 	BodyKey string `json:"body_key"`
+	// BodyTemplate is a Go text template that is used to build the message
+	// body from one or more values in the data (e.g.,
+	// {"ip":{{json .source.ip}},"user":{{json .user.name}}}). The template
+	// supports the same features as the template processor. This is only
+	// used in HTTP requests that send payloads to the server and is ignored
+	// if BodyKey is set.
+	//
+	// This is optional and has no default.
+	BodyTemplate string `json:"body_template"`
+	// ResponseKey retrieves a value from the response body, which must be an
+	// object. The key can be a JSONPath expression (e.g., $.data[0].score)
+	// or use the same syntax as Key (e.g., data.0.score). If the value does
+	// not exist, then the data is not modified.
+	//
+	// This is optional and defaults to using the entire response body.
+	ResponseKey string `json:"response_key"`
+	// SkipStatus are HTTP status codes (e.g., 404) or classes of codes (e.g.,
+	// 4xx) that cause the response to be ignored. If the response is ignored,
+	// then the data is not modified.
+	//
+	// This is optional and has no default.
+	SkipStatus []string `json:"skip_status"`
+	// ErrorStatus are HTTP status codes (e.g., 429) or classes of codes (e.g.,
+	// 4xx) that cause the processor to return an error. Server errors (5xx)
+	// are retried and return an error if all retries fail.
+	//
+	// This is optional and has no default.
+	ErrorStatus []string `json:"error_status"`
+	// RateLimit is the maximum number of requests per second that are made
+	// by the processor. If the limit is reached, then the processor waits
+	// until a request can be made.
+	//
+	// This is optional and defaults to no limit.
+	RateLimit float64 `json:"rate_limit"`
+	// Burst is the maximum number of requests that can be made at once
+	// before the rate limit is applied.
+	//
+	// This is optional and defaults to 1.
+	Burst int `json:"burst"`
+	// OffsetTTL is an offset (in seconds) used to determine the time-to-live
+	// (TTL) of responses that are cached in the KV store.
+	//
+	// This is optional and defaults to caching responses with no TTL.
+	OffsetTTL int `json:"offset_ttl"`
+	// KVOptions determine the type of KV store used to cache responses.
+	// Responses are cached using a SHA-256 hash of the method, the
+	// interpolated URL, the headers, and the message body; ignored responses
+	// and errors are not cached. Refer to
+	// internal/kv for more information.
+	//
+	// This is optional and responses are not cached by default.
+	KVOptions config.Config `json:"kv_options"`
 }
 
 // Closes resources opened by the processor.
 func (p procHTTP) Close(context.Context) error {
+	if p.IgnoreClose || p.kvStore == nil {
+		return nil
+	}
+
+	if p.kvStore.IsEnabled() {
+		if err := p.kvStore.Close(); err != nil {
+			return fmt.Errorf("close: http: %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -91,8 +173,73 @@ func newProcHTTP(ctx context.Context, cfg config.Config) (p procHTTP, err error)
 		return procHTTP{}, fmt.Errorf("process: http: option url: %v", errors.ErrMissingRequiredOption)
 	}
 
-	if p.Options.Method == "POST" && p.Options.BodyKey == "" {
-		return procHTTP{}, fmt.Errorf("process: http: options body_key: %v", errors.ErrMissingRequiredOption)
+	// methods are case-insensitive
+	p.Options.Method = strings.ToUpper(p.Options.Method)
+
+	//  validate option.method
+	if p.Options.Method != "" && !slices.Contains(
+		[]string{
+			gohttp.MethodGet,
+			gohttp.MethodPost,
+			gohttp.MethodPut,
+			gohttp.MethodPatch,
+		},
+		p.Options.Method) {
+		return procHTTP{}, fmt.Errorf("process: http: method %q: %v", p.Options.Method, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Method != "" && p.Options.Method != gohttp.MethodGet &&
+		p.Options.BodyKey == "" && p.Options.BodyTemplate == "" {
+		return procHTTP{}, fmt.Errorf("process: http: options body_key body_template: %v", errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.BodyKey == "" && p.Options.BodyTemplate != "" {
+		body, err := newProcTemplate(ctx, config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"template": p.Options.BodyTemplate,
+				},
+			},
+		})
+		if err != nil {
+			return procHTTP{}, fmt.Errorf("process: http: body_template: %v", err)
+		}
+
+		p.body = &body
+	}
+
+	for _, s := range append(p.Options.SkipStatus, p.Options.ErrorStatus...) {
+		if !httpStatusPattern.MatchString(s) {
+			return procHTTP{}, fmt.Errorf("process: http: status %q: %v", s, errors.ErrInvalidOption)
+		}
+	}
+
+	if p.Options.RateLimit < 0 || p.Options.Burst < 0 {
+		return procHTTP{}, fmt.Errorf("process: http: rate_limit %v burst %d: %v", p.Options.RateLimit, p.Options.Burst, errors.ErrInvalidOption)
+	}
+
+	if p.Options.RateLimit > 0 {
+		burst := p.Options.Burst
+		if burst == 0 {
+			burst = 1
+		}
+
+		p.limiter = rate.NewLimiter(rate.Limit(p.Options.RateLimit), burst)
+	}
+
+	if p.Options.KVOptions.Type != "" {
+		p.kvStore, err = kv.Get(p.Options.KVOptions)
+		if err != nil {
+			return procHTTP{}, fmt.Errorf("process: http: %v", err)
+		}
+
+		// lazy load the KV store
+		if !p.kvStore.IsEnabled() {
+			if err := p.kvStore.Setup(ctx); err != nil {
+				return procHTTP{}, fmt.Errorf("process: http: %v", err)
+			}
+		}
 	}
 
 	if !httpClient.IsEnabled() {
@@ -115,6 +262,11 @@ func newProcHTTP(ctx context.Context, cfg config.Config) (p procHTTP, err error)
 	return p, nil
 }
 
+// String returns the processor settings as an object.
+func (p procHTTP) String() string {
+	return toString(p)
+}
+
 // Batch processes one or more capsules with the processor. Conditions are
 // optionally applied to the data to enable processing.
 func (p procHTTP) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
@@ -149,68 +301,165 @@ func (p procHTTP) Apply(ctx context.Context, capsule config.Capsule) (config.Cap
 		return capsule, fmt.Errorf("process: http: %v", err)
 	}
 
-	switch p.Options.Method {
-	// POST only supports the object handling pattern
-	case gohttp.MethodPost:
-		// BodyKey is a requirement, otherwise there is no payload to send
-		if p.Options.BodyKey == "" {
-			return capsule, fmt.Errorf("process: http: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	method := p.Options.Method
+	if method == "" {
+		method = gohttp.MethodGet
+	}
+
+	var body []byte
+	if method != gohttp.MethodGet {
+		if p.Options.BodyKey != "" {
+			body = []byte(capsule.Get(p.Options.BodyKey).String())
+		} else {
+			tmp, err := p.body.Apply(ctx, capsule)
+			if err != nil {
+				return capsule, fmt.Errorf("process: http: %v", err)
+			}
+
+			body = tmp.Data()
 		}
+	}
 
-		body := capsule.Get(p.Options.BodyKey).String()
-		resp, err := httpClient.Post(ctx, url, body, p.headers...)
-		if err != nil && p.IgnoreErrors {
+	res, ok, err := p.request(ctx, method, url, body)
+	if err != nil {
+		if p.IgnoreErrors {
 			return capsule, nil
-		} else if err != nil {
-			return capsule, fmt.Errorf("process: http: %v", err)
 		}
-		defer resp.Body.Close()
 
-		res, err := parseResponse(resp)
+		return capsule, fmt.Errorf("process: http: %v", err)
+	}
+
+	// the response was skipped
+	if !ok {
+		return capsule, nil
+	}
+
+	// if SetKey exists, then the response body is written into the capsule
+	if p.SetKey != "" {
+		if p.Options.ResponseKey != "" {
+			err = capsule.SetRaw(p.SetKey, res)
+		} else {
+			err = capsule.Set(p.SetKey, res)
+		}
+
 		if err != nil {
 			return capsule, fmt.Errorf("process: http: %v", err)
 		}
 
-		// if SetKey exists, then the response body is written into the capsule,
-		// but otherwise the response is not stored and the capsule is returned
-		// as-is
-		if p.SetKey != "" {
-			if err := capsule.Set(p.SetKey, res); err != nil {
-				return capsule, fmt.Errorf("process: http: %v", err)
-			}
-			return capsule, nil
-		}
+		return capsule, nil
+	}
 
+	// requests that send payloads only support the object handling pattern,
+	// so the response is not stored and the capsule is returned as-is
+	if method != gohttp.MethodGet {
 		return capsule, nil
-	// GET must be the last condition and fallthrough since it is the default Method
-	case gohttp.MethodGet:
-		fallthrough
-	default:
-		resp, err := httpClient.Get(ctx, url, p.headers...)
-		if err != nil && p.IgnoreErrors {
-			return capsule, nil
-		} else if err != nil {
+	}
+
+	// data processing
+	if p.Options.ResponseKey != "" && bytes.HasPrefix(res, []byte(`"`)) {
+		var str string
+		if err := gojson.Unmarshal(res, &str); err != nil {
 			return capsule, fmt.Errorf("process: http: %v", err)
 		}
-		defer resp.Body.Close()
 
-		res, err := parseResponse(resp)
+		res = []byte(str)
+	}
+
+	capsule.SetData(res)
+	return capsule, nil
+}
+
+// cacheKey returns the key used to cache a response in the KV store. The
+// request is hashed so that secrets interpolated into the URL or headers are
+// not stored in plaintext.
+func (p procHTTP) cacheKey(method, url string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + "\n" + url + "\n"))
+	for _, hdr := range p.headers {
+		h.Write([]byte(hdr.Key + ":" + hdr.Value + "\n"))
+	}
+	h.Write(body)
+
+	return fmt.Sprintf("http:%x", h.Sum(nil))
+}
+
+// request returns the processed response from the cache or the server. If
+// the response is skipped, then false is returned.
+func (p procHTTP) request(ctx context.Context, method, url string, body []byte) ([]byte, bool, error) {
+	key := p.cacheKey(method, url, body)
+
+	if p.kvStore != nil {
+		val, err := p.kvStore.Get(ctx, key)
 		if err != nil {
-			return capsule, fmt.Errorf("process: http: %v", err)
+			return nil, false, err
 		}
 
-		// object processing
-		if p.SetKey != "" {
-			if err := capsule.Set(p.SetKey, res); err != nil {
-				return capsule, fmt.Errorf("process: http: %v", err)
-			}
-			return capsule, nil
+		if val != nil {
+			return []byte(fmt.Sprint(val)), true, nil
 		}
+	}
 
-		// data processing
-		capsule.SetData(res)
-		return capsule, nil
+	if p.limiter != nil {
+		if err := p.limiter.Wait(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	var resp *gohttp.Response
+	var err error
+	switch method {
+	case gohttp.MethodPost:
+		resp, err = httpClient.Post(ctx, url, body, p.headers...)
+	case gohttp.MethodPut:
+		resp, err = httpClient.Put(ctx, url, body, p.headers...)
+	case gohttp.MethodPatch:
+		resp, err = httpClient.Patch(ctx, url, body, p.headers...)
+	default:
+		resp, err = httpClient.Get(ctx, url, p.headers...)
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if httpStatusMatch(p.Options.ErrorStatus, resp.StatusCode) {
+		return nil, false, fmt.Errorf("URL %s: status %s", url, resp.Status)
+	}
+
+	if httpStatusMatch(p.Options.SkipStatus, resp.StatusCode) {
+		return nil, false, nil
+	}
+
+	res, err := parseResponse(resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if p.Options.ResponseKey != "" {
+		result := json.Get(res, httpPath(p.Options.ResponseKey))
+		if !result.Exists() {
+			return nil, false, nil
+		}
+
+		// values are stored as JSON so that their type is preserved
+		res = []byte(result.Raw)
+	}
+
+	if p.kvStore != nil {
+		if p.Options.OffsetTTL == 0 {
+			if err := p.kvStore.Set(ctx, key, string(res)); err != nil {
+				return nil, false, err
+			}
+		} else {
+			ttl := time.Now().Add(time.Duration(p.Options.OffsetTTL) * time.Second).Unix()
+			if err := p.kvStore.SetWithTTL(ctx, key, string(res), ttl); err != nil {
+				return nil, false, err
+			}
+		}
 	}
+
+	return res, true, nil
 }
 
 func parseResponse(resp *gohttp.Response) ([]byte, error) {
@@ -220,9 +469,9 @@ func parseResponse(resp *gohttp.Response) ([]byte, error) {
 	}
 
 	dst := &bytes.Buffer{}
-	if json.Valid(buf) {
+	if gojson.Valid(buf) {
 		// compact converts a multi-line object into a single-line object.
-		if err := json.Compact(dst, buf); err != nil {
+		if err := gojson.Compact(dst, buf); err != nil {
 			return nil, fmt.Errorf("process: http: %v", err)
 		}
 	} else {
@@ -231,3 +480,79 @@ func parseResponse(resp *gohttp.Response) ([]byte, error) {
 
 	return dst.Bytes(), nil
 }
+
+// httpStatusMatch returns true if a status code matches any of the patterns.
+func httpStatusMatch(patterns []string, code int) bool {
+	status := strconv.Itoa(code)
+	for _, p := range patterns {
+		if p == status || (strings.HasSuffix(p, "xx") && p[0] == status[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// httpPath converts a JSONPath expression (e.g., $.a['b'][0][*].c) to a
+// key (e.g., a.b.0.#.c). Keys that are not JSONPath expressions are returned
+// unchanged.
+func httpPath(path string) string {
+	if !strings.HasPrefix(path, "$") {
+		return path
+	}
+
+	var keys []string
+	for i := 1; i < len(path); {
+		switch path[i] {
+		case '.':
+			// names end at the next dot or bracket
+			j := i + 1
+			for j < len(path) && path[j] != '.' && path[j] != '[' {
+				j++
+			}
+
+			keys = append(keys, httpPathEscape(path[i+1:j]))
+			i = j
+		case '[':
+			j := strings.IndexByte(path[i:], ']')
+			if j == -1 {
+				return path
+			}
+
+			name := path[i+1 : i+j]
+			switch {
+			case name == "*":
+				keys = append(keys, "#")
+			case len(name) >= 2 && (name[0] == '\'' || name[0] == '"'):
+				keys = append(keys, httpPathEscape(name[1:len(name)-1]))
+			default:
+				keys = append(keys, name)
+			}
+
+			i += j + 1
+		default:
+			return path
+		}
+	}
+
+	return strings.Join(keys, ".")
+}
+
+// httpPathEscape escapes characters in a name that have special meaning
+// in keys.
+func httpPathEscape(name string) string {
+	if name == "*" {
+		return "#"
+	}
+
+	var b strings.Builder
+	for _, c := range name {
+		if strings.ContainsRune(`.*?|#@\`, c) {
+			b.WriteByte('\\')
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,383 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	gohttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/http"
+)
+
+var (
+	_ Applier = procHTTP{}
+	_ Batcher = procHTTP{}
+)
+
+// httpTestServer starts a server that responds to requests and returns a
+// counter of the requests it received. The response depends on the path:
+//
+// - /echo: the method and body of the request
+//
+// - /status/{code}: an empty response with the status code
+//
+// - all other paths return an object
+func httpTestServer(t testing.TB) (*httptest.Server, *int32) {
+	var count int32
+	serv := httptest.NewServer(gohttp.HandlerFunc(func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		atomic.AddInt32(&count, 1)
+
+		var code int
+		if _, err := fmt.Sscanf(r.URL.Path, "/status/%d", &code); err == nil {
+			w.WriteHeader(code)
+			return
+		}
+
+		if r.URL.Path == "/echo" {
+			body, _ := io.ReadAll(r.Body)
+			_, _ = fmt.Fprintf(w, "%s %s", r.Method, body)
+			return
+		}
+
+		_, _ = fmt.Fprintf(w, `{
+  "path": %q,
+  "data": [{"score": 42, "name": "foo"}]
+}`, r.URL.Path)
+	}))
+	t.Cleanup(serv.Close)
+
+	return serv, &count
+}
+
+var httpTests = []struct {
+	name     string
+	settings map[string]interface{}
+	test     []byte
+	expected []byte
+}{
+	{
+		"data",
+		map[string]interface{}{
+			"options": map[string]interface{}{
+				"url": "/${data}",
+			},
+		},
+		[]byte(`foo`),
+		[]byte(`{"path":"/foo","data":[{"score":42,"name":"foo"}]}`),
+	},
+	{
+		"JSON",
+		map[string]interface{}{
+			"key":     "foo",
+			"set_key": "bar",
+			"options": map[string]interface{}{
+				"url": "/${data}",
+			},
+		},
+		[]byte(`{"foo":"baz"}`),
+		[]byte(`{"foo":"baz","bar":{"path":"/baz","data":[{"score":42,"name":"foo"}]}}`),
+	},
+	{
+		"JSONPath response_key",
+		map[string]interface{}{
+			"key":     "foo",
+			"set_key": "bar",
+			"options": map[string]interface{}{
+				"url":          "/${data}",
+				"response_key": "$.data[0]['score']",
+			},
+		},
+		[]byte(`{"foo":"baz"}`),
+		[]byte(`{"foo":"baz","bar":42}`),
+	},
+	{
+		"string response_key",
+		map[string]interface{}{
+			"options": map[string]interface{}{
+				"url":          "/${data}",
+				"response_key": "data.0.name",
+			},
+		},
+		[]byte(`baz`),
+		[]byte(`foo`),
+	},
+	{
+		"skip_status",
+		map[string]interface{}{
+			"key":     "foo",
+			"set_key": "bar",
+			"options": map[string]interface{}{
+				"url":         "/status/${data}",
+				"skip_status": []string{"404"},
+			},
+		},
+		[]byte(`{"foo":"404"}`),
+		[]byte(`{"foo":"404"}`),
+	},
+	{
+		"PUT body_template",
+		map[string]interface{}{
+			"set_key": "bar",
+			"options": map[string]interface{}{
+				"method":        "PUT",
+				"url":           "/echo",
+				"body_template": `{"ip":{{json .ip}}}`,
+			},
+		},
+		[]byte(`{"ip":"10.0.0.1"}`),
+		[]byte(`{"ip":"10.0.0.1","bar":"PUT {\"ip\":\"10.0.0.1\"}"}`),
+	},
+	{
+		"PATCH body_key",
+		map[string]interface{}{
+			"set_key": "bar",
+			"options": map[string]interface{}{
+				"method":   "patch",
+				"url":      "/echo",
+				"body_key": "ip",
+			},
+		},
+		[]byte(`{"ip":"10.0.0.1"}`),
+		[]byte(`{"ip":"10.0.0.1","bar":"PATCH 10.0.0.1"}`),
+	},
+}
+
+// httpConfig returns a config with the URL prefixed by the server's URL.
+func httpConfig(serv *httptest.Server, settings map[string]interface{}) config.Config {
+	s := map[string]interface{}{}
+	for k, v := range settings {
+		s[k] = v
+	}
+
+	opts := map[string]interface{}{}
+	for k, v := range settings["options"].(map[string]interface{}) {
+		opts[k] = v
+	}
+
+	opts["url"] = serv.URL + opts["url"].(string)
+	s["options"] = opts
+
+	return config.Config{
+		Type:     "http",
+		Settings: s,
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	ctx := context.TODO()
+	serv, _ := httpTestServer(t)
+
+	for _, test := range httpTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule := config.NewCapsule()
+			capsule.SetData(test.test)
+
+			proc, err := newProcHTTP(ctx, httpConfig(serv, test.settings))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestHTTPErrorStatus(t *testing.T) {
+	ctx := context.TODO()
+	serv, _ := httpTestServer(t)
+
+	settings := map[string]interface{}{
+		"options": map[string]interface{}{
+			"url":          "/status/403",
+			"error_status": []string{"4xx"},
+		},
+	}
+
+	proc, err := newProcHTTP(ctx, httpConfig(serv, settings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := config.NewCapsule()
+	if _, err := proc.Apply(ctx, capsule); err == nil {
+		t.Error("expected error")
+	}
+
+	settings["ignore_errors"] = true
+	proc, err = newProcHTTP(ctx, httpConfig(serv, settings))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := proc.Apply(ctx, capsule); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHTTPCache(t *testing.T) {
+	ctx := context.TODO()
+	serv, count := httpTestServer(t)
+
+	proc, err := newProcHTTP(ctx, httpConfig(serv, map[string]interface{}{
+		"options": map[string]interface{}{
+			"url":          "/${data}",
+			"response_key": "path",
+			"offset_ttl":   60,
+			"kv_options": map[string]interface{}{
+				"type": "memory",
+				"settings": map[string]interface{}{
+					"capacity": 102,
+				},
+			},
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proc.Close(ctx)
+
+	for _, data := range []string{"foo", "bar", "foo", "foo"} {
+		capsule := config.NewCapsule()
+		capsule.SetData([]byte(data))
+
+		result, err := proc.Apply(ctx, capsule)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := []byte("/" + data); !bytes.Equal(result.Data(), expected) {
+			t.Errorf("expected %s, got %s", expected, result.Data())
+		}
+	}
+
+	if c := atomic.LoadInt32(count); c != 2 {
+		t.Errorf("expected 2 requests, got %d", c)
+	}
+}
+
+func TestHTTPCacheKey(t *testing.T) {
+	url := "https://example.com/?api_key=secret"
+
+	a := procHTTP{}
+	b := procHTTP{headers: []http.Header{{Key: "Authorization", Value: "secret"}}}
+
+	key := a.cacheKey(gohttp.MethodGet, url, nil)
+	if strings.Contains(key, "secret") {
+		t.Errorf("expected hashed key, got %s", key)
+	}
+
+	if key == b.cacheKey(gohttp.MethodGet, url, nil) {
+		t.Error("expected different keys for different headers")
+	}
+
+	if key == a.cacheKey(gohttp.MethodPost, url, []byte(`{}`)) {
+		t.Error("expected different keys for different requests")
+	}
+}
+
+func TestHTTPRateLimit(t *testing.T) {
+	ctx := context.TODO()
+	serv, _ := httpTestServer(t)
+
+	proc, err := newProcHTTP(ctx, httpConfig(serv, map[string]interface{}{
+		"options": map[string]interface{}{
+			"url":        "/foo",
+			"rate_limit": 20,
+		},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := proc.Apply(ctx, config.NewCapsule()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the first request is immediate and the others wait 50ms each
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("expected at least 150ms, got %v", elapsed)
+	}
+}
+
+func TestHTTPInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, options := range []map[string]interface{}{
+		{"url": "http://localhost", "method": "DELETE"},
+		{"url": "http://localhost", "method": "PUT"},
+		{"url": "http://localhost", "skip_status": []string{"40"}},
+		{"url": "http://localhost", "error_status": []string{"6xx"}},
+		{"url": "http://localhost", "rate_limit": -1},
+	} {
+		_, err := newProcHTTP(ctx, config.Config{
+			Type: "http",
+			Settings: map[string]interface{}{
+				"options": options,
+			},
+		})
+		if err == nil {
+			t.Errorf("expected error for options %v", options)
+		}
+	}
+}
+
+func TestHTTPPath(t *testing.T) {
+	var tests = []struct {
+		path     string
+		expected string
+	}{
+		{"foo.bar", "foo.bar"},
+		{"$.foo.bar", "foo.bar"},
+		{"$.data[0].score", "data.0.score"},
+		{"$['a.b'][*].c", `a\.b.#.c`},
+		{"$.items.*.id", "items.#.id"},
+	}
+
+	for _, test := range tests {
+		if path := httpPath(test.path); path != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, path)
+		}
+	}
+}
+
+func benchmarkHTTP(b *testing.B, applier procHTTP, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkHTTP(b *testing.B) {
+	serv, _ := httpTestServer(b)
+	capsule := config.NewCapsule()
+	for _, test := range httpTests {
+		proc, err := newProcHTTP(context.TODO(), httpConfig(serv, test.settings))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkHTTP(b, proc, capsule)
+			},
+		)
+	}
+}
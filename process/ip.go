@@ -0,0 +1,231 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"strconv"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/ip"
+)
+
+// ip processes data by converting, normalizing, and anonymizing IP
+// addresses. IPv4-mapped IPv6 addresses (e.g., ::ffff:10.0.0.1) are
+// processed as IPv4 addresses.
+//
+// This processor supports the data and object handling patterns.
+type procIP struct {
+	process
+	Options procIPOptions `json:"options"`
+
+	prefixes   []netip.Prefix
+	ipv4Prefix int
+	ipv6Prefix int
+}
+
+type procIPOptions struct {
+	// Type determines how the IP address is processed.
+	//
+	// Must be one of:
+	//
+	// - integer: converts the address to an integer. IPv4 addresses are
+	// converted to numbers and IPv6 addresses are converted to strings
+	// that contain a number (IPv6 addresses are larger than the maximum
+	// integer supported by JSON).
+	//
+	// - cidr: returns true if the address is in any of the CIDRs,
+	// otherwise returns false
+	//
+	// - anonymize: truncates the address to its network prefix (e.g.,
+	// 192.168.1.100 becomes 192.168.1.0)
+	//
+	// - normalize: converts the address to its canonical form (e.g.,
+	// ::ffff:10.0.0.1 becomes 10.0.0.1 and 2001:DB8:0::1 becomes 2001:db8::1)
+	Type string `json:"type"`
+	// CIDRs are the networks (e.g., 10.0.0.0/8, fc00::/7) that addresses
+	// are compared against.
+	//
+	// This is required if Type is cidr.
+	CIDRs []string `json:"cidrs"`
+	// IPv4Prefix is the prefix length (0 to 32) that IPv4 addresses are
+	// anonymized to.
+	//
+	// This is optional and defaults to 24.
+	IPv4Prefix *int `json:"ipv4_prefix"`
+	// IPv6Prefix is the prefix length (0 to 128) that IPv6 addresses are
+	// anonymized to.
+	//
+	// This is optional and defaults to 48.
+	IPv6Prefix *int `json:"ipv6_prefix"`
+}
+
+// String returns the processor settings as an object.
+func (p procIP) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procIP) Close(context.Context) error {
+	return nil
+}
+
+// Create a new IP processor.
+func newProcIP(ctx context.Context, cfg config.Config) (p procIP, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procIP{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procIP{}, err
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"integer",
+			"cidr",
+			"anonymize",
+			"normalize",
+		},
+		p.Options.Type) {
+		return procIP{}, fmt.Errorf("process: ip: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	// error early if required options are missing
+	if p.Options.Type == "cidr" && len(p.Options.CIDRs) == 0 {
+		return procIP{}, fmt.Errorf("process: ip: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	for _, c := range p.Options.CIDRs {
+		prefix, err := netip.ParsePrefix(c)
+		if err != nil {
+			return procIP{}, fmt.Errorf("process: ip: cidr %s: %v", c, err)
+		}
+
+		// IPv4-mapped IPv6 networks are compared as IPv4 networks
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+
+		p.prefixes = append(p.prefixes, prefix.Masked())
+	}
+
+	p.ipv4Prefix = 24
+	if p.Options.IPv4Prefix != nil {
+		p.ipv4Prefix = *p.Options.IPv4Prefix
+	}
+
+	p.ipv6Prefix = 48
+	if p.Options.IPv6Prefix != nil {
+		p.ipv6Prefix = *p.Options.IPv6Prefix
+	}
+
+	if p.ipv4Prefix < 0 || p.ipv4Prefix > 32 {
+		return procIP{}, fmt.Errorf("process: ip: ipv4_prefix %d: %v", p.ipv4Prefix, errors.ErrInvalidOption)
+	}
+
+	if p.ipv6Prefix < 0 || p.ipv6Prefix > 128 {
+		return procIP{}, fmt.Errorf("process: ip: ipv6_prefix %d: %v", p.ipv6Prefix, errors.ErrInvalidOption)
+	}
+
+	return p, nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procIP) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procIP) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key)
+		if !result.Exists() {
+			return capsule, nil
+		}
+
+		value, err := p.ip(result.String())
+		if err != nil {
+			return capsule, fmt.Errorf("process: ip: %v", err)
+		}
+
+		if err := capsule.Set(p.SetKey, value); err != nil {
+			return capsule, fmt.Errorf("process: ip: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, err := p.ip(string(capsule.Data()))
+		if err != nil {
+			return capsule, fmt.Errorf("process: ip: %v", err)
+		}
+
+		switch v := value.(type) {
+		case uint32:
+			capsule.SetData([]byte(strconv.FormatUint(uint64(v), 10)))
+		case bool:
+			capsule.SetData([]byte(strconv.FormatBool(v)))
+		case string:
+			capsule.SetData([]byte(v))
+		}
+
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: ip: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+func (p procIP) ip(s string) (interface{}, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", s, ip.ErrInvalidIPAddress)
+	}
+
+	// zones are not part of the address and are removed
+	addr = addr.Unmap().WithZone("")
+
+	switch p.Options.Type {
+	case "integer":
+		if addr.Is4() {
+			b := addr.As4()
+			return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+		}
+
+		b := addr.As16()
+		return new(big.Int).SetBytes(b[:]).String(), nil
+	case "cidr":
+		for _, prefix := range p.prefixes {
+			if prefix.Contains(addr) {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	case "anonymize":
+		bits := p.ipv6Prefix
+		if addr.Is4() {
+			bits = p.ipv4Prefix
+		}
+
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return nil, err
+		}
+
+		return prefix.Addr().String(), nil
+	default:
+		return addr.String(), nil
+	}
+}
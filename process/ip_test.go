@@ -0,0 +1,247 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procIP{}
+	_ Batcher = procIP{}
+)
+
+var ipTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON integer",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type": "integer",
+				},
+			},
+		},
+		[]byte(`{"ip":"10.0.0.1"}`),
+		[]byte(`{"ip":167772161}`),
+		nil,
+	},
+	{
+		"JSON integer IPv6",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type": "integer",
+				},
+			},
+		},
+		[]byte(`{"ip":"2001:db8::1"}`),
+		[]byte(`{"ip":"42540766411282592856903984951653826561"}`),
+		nil,
+	},
+	{
+		"JSON cidr",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type":  "cidr",
+					"cidrs": []string{"192.168.0.0/16", "10.0.0.0/8"},
+				},
+			},
+		},
+		[]byte(`{"ip":"::ffff:10.1.2.3"}`),
+		[]byte(`{"ip":true}`),
+		nil,
+	},
+	{
+		"JSON cidr IPv4-mapped network",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type":  "cidr",
+					"cidrs": []string{"::ffff:10.0.0.0/104"},
+				},
+			},
+		},
+		[]byte(`{"ip":"10.1.2.3"}`),
+		[]byte(`{"ip":true}`),
+		nil,
+	},
+	{
+		"JSON cidr no match",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type":  "cidr",
+					"cidrs": []string{"192.168.0.0/16", "fc00::/7"},
+				},
+			},
+		},
+		[]byte(`{"ip":"8.8.8.8"}`),
+		[]byte(`{"ip":false}`),
+		nil,
+	},
+	{
+		"JSON anonymize",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type": "anonymize",
+				},
+			},
+		},
+		[]byte(`{"ip":"192.168.1.100"}`),
+		[]byte(`{"ip":"192.168.1.0"}`),
+		nil,
+	},
+	{
+		"JSON anonymize zero prefix",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type":        "anonymize",
+					"ipv4_prefix": 0,
+				},
+			},
+		},
+		[]byte(`{"ip":"192.168.1.100"}`),
+		[]byte(`{"ip":"0.0.0.0"}`),
+		nil,
+	},
+	{
+		"JSON anonymize IPv6",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "ip",
+				"options": map[string]interface{}{
+					"type":        "anonymize",
+					"ipv6_prefix": 32,
+				},
+			},
+		},
+		[]byte(`{"ip":"2001:db8:85a3::8a2e:370:7334"}`),
+		[]byte(`{"ip":"2001:db8::"}`),
+		nil,
+	},
+	{
+		"data normalize",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type": "normalize",
+				},
+			},
+		},
+		[]byte(`::FFFF:10.0.0.1`),
+		[]byte(`10.0.0.1`),
+		nil,
+	},
+	{
+		"data normalize IPv6",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type": "normalize",
+				},
+			},
+		},
+		[]byte(`2001:DB8:0:0::1`),
+		[]byte(`2001:db8::1`),
+		nil,
+	},
+	{
+		"data integer",
+		config.Config{
+			Type: "ip",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type": "integer",
+				},
+			},
+		},
+		[]byte(`255.255.255.255`),
+		[]byte(`4294967295`),
+		nil,
+	},
+}
+
+func TestIP(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range ipTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcIP(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkIP(b *testing.B, applier procIP, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkIP(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range ipTests {
+		proc, err := newProcIP(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkIP(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,223 @@
+package process
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/json"
+)
+
+// map processes data by moving values in an object to new keys, and is
+// intended to normalize data to a schema (e.g., ECS, OCSF) in a single
+// processor. Each field in the mapping moves the value of a source key to a
+// target key and can optionally change the type or time format of the value.
+// All values are read before the object is modified, so fields can swap keys.
+// Mappings for common data sources are in build/config/mappings.
+//
+// This processor supports the object handling pattern.
+type procMap struct {
+	process
+	Options procMapOptions `json:"options"`
+
+	// times are the time processors of the fields, which are nil if the
+	// field does not convert time.
+	times []*procTime
+}
+
+type procMapOptions struct {
+	// Fields are the mappings that are applied to the object.
+	Fields []procMapField `json:"fields"`
+	// DropUnmapped determines if values that are not mapped are removed from
+	// the object. If true, then the processed object only contains the
+	// target keys of the mapping (metadata is not modified).
+	//
+	// This is optional and defaults to false (values that are not mapped are
+	// kept in the object).
+	DropUnmapped bool `json:"drop_unmapped"`
+}
+
+type procMapField struct {
+	// Key retrieves the value from the object. The value is removed from
+	// the object after it is mapped.
+	//
+	// This is optional if Default is set.
+	Key string `json:"key"`
+	// SetKey inserts the value into the object. Keys that begin with special
+	// characters (e.g., @timestamp) must be escaped (e.g., \\@timestamp).
+	SetKey string `json:"set_key"`
+	// Convert is the target conversion type of the value. Refer to the
+	// convert processor for supported types.
+	//
+	// This is optional and defaults to not converting the value.
+	Convert string `json:"convert"`
+	// Time converts the value between time formats. Refer to the time
+	// processor for supported options.
+	//
+	// This is optional and defaults to not converting the value.
+	Time *procTimeOptions `json:"time"`
+	// Default is inserted into the object if the value does not exist or is
+	// null.
+	//
+	// This is optional and defaults to not inserting the value.
+	Default interface{} `json:"default"`
+}
+
+// Create a new map processor.
+func newProcMap(ctx context.Context, cfg config.Config) (p procMap, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procMap{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procMap{}, err
+	}
+
+	// error early if required options are missing
+	if len(p.Options.Fields) == 0 {
+		return procMap{}, fmt.Errorf("process: map: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	for _, f := range p.Options.Fields {
+		if f.SetKey == "" || (f.Key == "" && f.Default == nil) {
+			return procMap{}, fmt.Errorf("process: map: field %+v: %v", f, errors.ErrMissingRequiredOption)
+		}
+
+		//  validate option.fields.convert
+		if f.Convert != "" && !slices.Contains(
+			[]string{
+				"bool",
+				"int",
+				"float",
+				"uint",
+				"string",
+			},
+			f.Convert) {
+			return procMap{}, fmt.Errorf("process: map: convert %q: %v", f.Convert, errors.ErrInvalidOption)
+		}
+
+		if f.Time != nil && f.Convert != "" {
+			return procMap{}, fmt.Errorf("process: map: field %s: convert and time: %v", f.SetKey, errors.ErrInvalidOption)
+		}
+
+		if f.Time == nil {
+			p.times = append(p.times, nil)
+			continue
+		}
+
+		// time options are validated by the time processor
+		t, err := newProcTime(ctx, config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": *f.Time,
+			},
+		})
+		if err != nil {
+			return procMap{}, fmt.Errorf("process: map: field %s: %v", f.SetKey, err)
+		}
+
+		p.times = append(p.times, &t)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procMap) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procMap) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procMap) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procMap) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	values := make([]interface{}, len(p.Options.Fields))
+	for i, f := range p.Options.Fields {
+		var res json.Result
+		if f.Key != "" {
+			res = capsule.Get(f.Key)
+		}
+
+		if !res.Exists() || res.Type.String() == "Null" {
+			values[i] = f.Default
+			continue
+		}
+
+		value, err := p.convert(i, res)
+		if err != nil {
+			return capsule, fmt.Errorf("process: map: key %s: %v", f.Key, err)
+		}
+
+		values[i] = value
+	}
+
+	if p.Options.DropUnmapped {
+		capsule.SetData([]byte{})
+	} else {
+		for _, f := range p.Options.Fields {
+			if f.Key == "" || f.Key == f.SetKey {
+				continue
+			}
+
+			if err := capsule.Delete(f.Key); err != nil {
+				return capsule, fmt.Errorf("process: map: %v", err)
+			}
+		}
+	}
+
+	for i, f := range p.Options.Fields {
+		if values[i] == nil {
+			continue
+		}
+
+		var err error
+		if res, ok := values[i].(json.Result); ok {
+			err = capsule.SetRaw(f.SetKey, res.Raw)
+		} else {
+			err = capsule.Set(f.SetKey, values[i])
+		}
+
+		if err != nil {
+			return capsule, fmt.Errorf("process: map: %v", err)
+		}
+	}
+
+	return capsule, nil
+}
+
+// convert returns the mapped value of a field. Values that are not converted
+// are returned as results so that their JSON type is not changed.
+func (p procMap) convert(i int, res json.Result) (interface{}, error) {
+	if p.times[i] != nil {
+		return p.times[i].procTime(res)
+	}
+
+	switch p.Options.Fields[i].Convert {
+	case "bool":
+		return res.Bool(), nil
+	case "int":
+		return res.Int(), nil
+	case "float":
+		return res.Float(), nil
+	case "uint":
+		return res.Uint(), nil
+	case "string":
+		return res.String(), nil
+	default:
+		return res, nil
+	}
+}
@@ -0,0 +1,242 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procMap{}
+	_ Batcher = procMap{}
+)
+
+var mapTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"move",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "src_ip",
+							"set_key": "source.ip",
+						},
+						map[string]interface{}{
+							"key":     "src_port",
+							"set_key": "source.port",
+							"convert": "int",
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"src_ip":"10.1.1.1","src_port":"443","action":"allow"}`),
+		[]byte(`{"action":"allow","source":{"ip":"10.1.1.1","port":443}}`),
+		nil,
+	},
+	{
+		"swap",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "foo",
+							"set_key": "bar",
+						},
+						map[string]interface{}{
+							"key":     "bar",
+							"set_key": "foo",
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"foo":1,"bar":{"baz":2}}`),
+		[]byte(`{"bar":1,"foo":{"baz":2}}`),
+		nil,
+	},
+	{
+		"time",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key": "ts",
+							// keys that begin with @ must be escaped
+							"set_key": `\@timestamp`,
+							"time": map[string]interface{}{
+								"format":     "unix",
+								"set_format": "2006-01-02T15:04:05.000Z",
+							},
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"ts":1639877490.061}`),
+		[]byte(`{"@timestamp":"2021-12-19T01:31:30.061Z"}`),
+		nil,
+	},
+	{
+		"time formats",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "ts",
+							"set_key": "time",
+							"time": map[string]interface{}{
+								"formats":    []string{"unix", "2006-01-02 15:04:05"},
+								"set_format": "unix",
+							},
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"ts":"2021-12-19 01:31:30"}`),
+		[]byte(`{"time":1639877490}`),
+		nil,
+	},
+	{
+		"defaults",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "outcome",
+							"set_key": "event.outcome",
+							"default": "unknown",
+						},
+						map[string]interface{}{
+							"set_key": "event.kind",
+							"default": "event",
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"outcome":null,"foo":"bar"}`),
+		[]byte(`{"foo":"bar","event":{"outcome":"unknown","kind":"event"}}`),
+		nil,
+	},
+	{
+		"drop_unmapped",
+		config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"drop_unmapped": true,
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "user",
+							"set_key": "user.name",
+						},
+						map[string]interface{}{
+							"key":     "missing",
+							"set_key": "user.id",
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"user":"foo","password":"bar"}`),
+		[]byte(`{"user":{"name":"foo"}}`),
+		nil,
+	},
+}
+
+func TestMap(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range mapTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcMap(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestMapInvalidTime(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, options := range []map[string]interface{}{
+		{"format": "unix"},
+		{"format": "unix", "set_format": "unix", "location": "Mars/Base"},
+		{"format": "unix", "set_format": "unix", "offset": "1x"},
+	} {
+		_, err := newProcMap(ctx, config.Config{
+			Type: "map",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "ts",
+							"set_key": "time",
+							"time":    options,
+						},
+					},
+				},
+			},
+		})
+		if err == nil {
+			t.Errorf("expected error for options %v", options)
+		}
+	}
+}
+
+func benchmarkMap(b *testing.B, applier procMap, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkMap(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range mapTests {
+		proc, err := newProcMap(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkMap(b, proc, capsule)
+			},
+		)
+	}
+}
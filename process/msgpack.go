@@ -0,0 +1,197 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/base64"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// msgpack processes data by converting it to and from MessagePack
+// (https://msgpack.org/). MessagePack maps are converted to JSON objects
+// with sorted keys, and MessagePack binary values are converted to base64
+// encoded strings.
+//
+// When handling objects, MessagePack data is stored in the object as base64
+// encoded strings.
+//
+// This processor supports the data and object handling patterns.
+type procMsgpack struct {
+	process
+	Options procMsgpackOptions `json:"options"`
+}
+
+type procMsgpackOptions struct {
+	// Direction determines whether data is encoded or decoded.
+	//
+	// Must be one of:
+	//
+	// - to: encode JSON to MessagePack
+	//
+	// - from: decode MessagePack to JSON
+	Direction string `json:"direction"`
+}
+
+// Create a new MessagePack processor.
+func newProcMsgpack(ctx context.Context, cfg config.Config) (p procMsgpack, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procMsgpack{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procMsgpack{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procMsgpack{}, fmt.Errorf("process: msgpack: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procMsgpack{}, fmt.Errorf("process: msgpack: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procMsgpack) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procMsgpack) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procMsgpack) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procMsgpack) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key)
+
+		switch p.Options.Direction {
+		case "from":
+			b, err := base64.Decode([]byte(result.String()))
+			if err != nil {
+				return capsule, fmt.Errorf("process: msgpack: %v", err)
+			}
+
+			value, err := msgpackFrom(b)
+			if err != nil {
+				return capsule, fmt.Errorf("process: msgpack: %v", err)
+			}
+
+			if err := capsule.SetRaw(p.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: msgpack: %v", err)
+			}
+		case "to":
+			value, err := msgpackTo([]byte(result.Raw))
+			if err != nil {
+				return capsule, fmt.Errorf("process: msgpack: %v", err)
+			}
+
+			if err := capsule.Set(p.SetKey, string(base64.Encode(value))); err != nil {
+				return capsule, fmt.Errorf("process: msgpack: %v", err)
+			}
+		default:
+			return capsule, fmt.Errorf("process: msgpack: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		var value []byte
+		var err error
+
+		switch p.Options.Direction {
+		case "from":
+			value, err = msgpackFrom(capsule.Data())
+		case "to":
+			value, err = msgpackTo(capsule.Data())
+		default:
+			return capsule, fmt.Errorf("process: msgpack: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		if err != nil {
+			return capsule, fmt.Errorf("process: msgpack: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: msgpack: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// msgpackFrom decodes MessagePack data into JSON.
+func msgpackFrom(data []byte) ([]byte, error) {
+	var v interface{}
+	if err := msgpack.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	return gojson.Marshal(v)
+}
+
+// msgpackTo encodes JSON into MessagePack data. JSON numbers are encoded as
+// integers if they have no fractional component, otherwise they are encoded
+// as floats.
+func msgpackTo(data []byte) ([]byte, error) {
+	dec := gojson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return msgpack.Marshal(msgpackNumbers(v))
+}
+
+// msgpackNumbers recursively converts JSON numbers to their native types.
+func msgpackNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case gojson.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = msgpackNumbers(val)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = msgpackNumbers(val)
+		}
+	}
+
+	return v
+}
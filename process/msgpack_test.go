@@ -0,0 +1,132 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procMsgpack{}
+	_ Batcher = procMsgpack{}
+)
+
+var msgpackTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON from",
+		config.Config{
+			Type: "msgpack",
+			Settings: map[string]interface{}{
+				"key":     "msgpack",
+				"set_key": "msgpack",
+				"options": map[string]interface{}{
+					"direction": "from",
+				},
+			},
+		},
+		[]byte(`{"msgpack":"g6NxdXiSw8s/+AAAAAAAAKNmb2+jYmFyo2JhetMAAAAAAAAAAQ=="}`),
+		[]byte(`{"msgpack":{"baz":1,"foo":"bar","qux":[true,1.5]}}`),
+		nil,
+	},
+	{
+		"JSON to",
+		config.Config{
+			Type: "msgpack",
+			Settings: map[string]interface{}{
+				"key":     "msgpack",
+				"set_key": "msgpack",
+				"options": map[string]interface{}{
+					"direction": "to",
+				},
+			},
+		},
+		[]byte(`{"msgpack":"foo"}`),
+		[]byte(`{"msgpack":"o2Zvbw=="}`),
+		nil,
+	},
+	{
+		"data from",
+		config.Config{
+			Type: "msgpack",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+				},
+			},
+		},
+		[]byte("\x82\xa3foo\xa3bar\xa3baz\xd3\x00\x00\x00\x00\x00\x00\x00\x01"),
+		[]byte(`{"baz":1,"foo":"bar"}`),
+		nil,
+	},
+	{
+		"data to",
+		config.Config{
+			Type: "msgpack",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "to",
+				},
+			},
+		},
+		[]byte(`[1,1.5,"foo"]`),
+		[]byte("\x93\xd3\x00\x00\x00\x00\x00\x00\x00\x01\xcb?\xf8\x00\x00\x00\x00\x00\x00\xa3foo"),
+		nil,
+	},
+}
+
+func TestMsgpack(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range msgpackTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcMsgpack(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkMsgpack(b *testing.B, applier procMsgpack, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkMsgpack(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range msgpackTests {
+		proc, err := newProcMsgpack(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkMsgpack(b, proc, capsule)
+			},
+		)
+	}
+}
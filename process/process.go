@@ -57,16 +57,24 @@ type Applier interface {
 // NewApplier returns a configured Applier from a processor configuration.
 func NewApplier(ctx context.Context, cfg config.Config) (Applier, error) {
 	switch cfg.Type {
+	case "avro":
+		return newProcAvro(ctx, cfg)
 	case "aws_dynamodb":
 		return newProcAWSDynamoDB(ctx, cfg)
 	case "aws_lambda":
 		return newProcAWSLambda(ctx, cfg)
 	case "base64":
 		return newProcBase64(ctx, cfg)
+	case "cache":
+		return newProcCache(ctx, cfg)
 	case "capture":
 		return newProcCapture(ctx, cfg)
 	case "case":
 		return newProcCase(ctx, cfg)
+	case "community_id":
+		return newProcCommunityID(ctx, cfg)
+	case "compress":
+		return newProcCompress(ctx, cfg)
 	case "convert":
 		return newProcConvert(ctx, cfg)
 	case "copy":
@@ -77,6 +85,14 @@ func NewApplier(ctx context.Context, cfg config.Config) (Applier, error) {
 		return newProcDNS(ctx, cfg)
 	case "domain":
 		return newProcDomain(ctx, cfg)
+	case "encode":
+		return newProcEncode(ctx, cfg)
+	case "encrypt":
+		return newProcEncrypt(ctx, cfg)
+	case "error_handler":
+		return newProcErrorHandler(ctx, cfg)
+	case "expr":
+		return newProcExpr(ctx, cfg)
 	case "flatten":
 		return newProcFlatten(ctx, cfg)
 	case "for_each":
@@ -91,6 +107,8 @@ func NewApplier(ctx context.Context, cfg config.Config) (Applier, error) {
 		return newProcHTTP(ctx, cfg)
 	case "insert":
 		return newProcInsert(ctx, cfg)
+	case "ip":
+		return newProcIP(ctx, cfg)
 	case "ip_database":
 		return newProcIPDatabase(ctx, cfg)
 	case "join":
@@ -99,18 +117,40 @@ func NewApplier(ctx context.Context, cfg config.Config) (Applier, error) {
 		return newProcJQ(ctx, cfg)
 	case "kv_store":
 		return newProcKVStore(ctx, cfg)
+	case "map":
+		return newProcMap(ctx, cfg)
 	case "math":
 		return newProcMath(ctx, cfg)
+	case "msgpack":
+		return newProcMsgpack(ctx, cfg)
 	case "pipeline":
 		return newProcPipeline(ctx, cfg)
 	case "pretty_print":
 		return newProcPrettyPrint(ctx, cfg)
+	case "protobuf":
+		return newProcProtobuf(ctx, cfg)
+	case "redact":
+		return newProcRedact(ctx, cfg)
 	case "replace":
 		return newProcReplace(ctx, cfg)
+	case "script":
+		return newProcScript(ctx, cfg)
 	case "split":
 		return newProcSplit(ctx, cfg)
+	case "switch":
+		return newProcSwitch(ctx, cfg)
+	case "template":
+		return newProcTemplate(ctx, cfg)
 	case "time":
 		return newProcTime(ctx, cfg)
+	case "tokenize":
+		return newProcTokenize(ctx, cfg)
+	case "url":
+		return newProcURL(ctx, cfg)
+	case "user_agent":
+		return newProcUserAgent(ctx, cfg)
+	case "wasm":
+		return newProcWasm(ctx, cfg)
 	default:
 		return nil, fmt.Errorf("process: new_applier: type %q settings %+v: %v", cfg.Type, cfg.Settings, errors.ErrInvalidFactoryInput)
 	}
@@ -179,22 +219,34 @@ func NewBatcher(ctx context.Context, cfg config.Config) (Batcher, error) { //nol
 	switch cfg.Type {
 	case "aggregate":
 		return newProcAggregate(ctx, cfg)
+	case "avro":
+		return newProcAvro(ctx, cfg)
 	case "aws_dynamodb":
 		return newProcAWSDynamoDB(ctx, cfg)
 	case "aws_lambda":
 		return newProcAWSLambda(ctx, cfg)
 	case "base64":
 		return newProcBase64(ctx, cfg)
+	case "cache":
+		return newProcCache(ctx, cfg)
 	case "capture":
 		return newProcCapture(ctx, cfg)
 	case "case":
 		return newProcCase(ctx, cfg)
+	case "community_id":
+		return newProcCommunityID(ctx, cfg)
+	case "compress":
+		return newProcCompress(ctx, cfg)
 	case "convert":
 		return newProcConvert(ctx, cfg)
 	case "copy":
 		return newProcCopy(ctx, cfg)
+	case "correlate":
+		return newProcCorrelate(ctx, cfg)
 	case "count":
 		return newProcCount(ctx, cfg)
+	case "dedupe":
+		return newProcDedupe(ctx, cfg)
 	case "delete":
 		return newProcDelete(ctx, cfg)
 	case "dns":
@@ -203,8 +255,16 @@ func NewBatcher(ctx context.Context, cfg config.Config) (Batcher, error) { //nol
 		return newProcDomain(ctx, cfg)
 	case "drop":
 		return newProcDrop(ctx, cfg)
+	case "encode":
+		return newProcEncode(ctx, cfg)
+	case "encrypt":
+		return newProcEncrypt(ctx, cfg)
+	case "error_handler":
+		return newProcErrorHandler(ctx, cfg)
 	case "expand":
 		return newProcExpand(ctx, cfg)
+	case "expr":
+		return newProcExpr(ctx, cfg)
 	case "flatten":
 		return newProcFlatten(ctx, cfg)
 	case "for_each":
@@ -219,6 +279,8 @@ func NewBatcher(ctx context.Context, cfg config.Config) (Batcher, error) { //nol
 		return newProcHTTP(ctx, cfg)
 	case "insert":
 		return newProcInsert(ctx, cfg)
+	case "ip":
+		return newProcIP(ctx, cfg)
 	case "ip_database":
 		return newProcIPDatabase(ctx, cfg)
 	case "join":
@@ -227,18 +289,44 @@ func NewBatcher(ctx context.Context, cfg config.Config) (Batcher, error) { //nol
 		return newProcJQ(ctx, cfg)
 	case "kv_store":
 		return newProcKVStore(ctx, cfg)
+	case "map":
+		return newProcMap(ctx, cfg)
 	case "math":
 		return newProcMath(ctx, cfg)
+	case "msgpack":
+		return newProcMsgpack(ctx, cfg)
 	case "pipeline":
 		return newProcPipeline(ctx, cfg)
 	case "pretty_print":
 		return newProcPrettyPrint(ctx, cfg)
+	case "protobuf":
+		return newProcProtobuf(ctx, cfg)
+	case "redact":
+		return newProcRedact(ctx, cfg)
 	case "replace":
 		return newProcReplace(ctx, cfg)
+	case "sample":
+		return newProcSample(ctx, cfg)
+	case "script":
+		return newProcScript(ctx, cfg)
 	case "split":
 		return newProcSplit(ctx, cfg)
+	case "switch":
+		return newProcSwitch(ctx, cfg)
+	case "template":
+		return newProcTemplate(ctx, cfg)
 	case "time":
 		return newProcTime(ctx, cfg)
+	case "tokenize":
+		return newProcTokenize(ctx, cfg)
+	case "url":
+		return newProcURL(ctx, cfg)
+	case "user_agent":
+		return newProcUserAgent(ctx, cfg)
+	case "wasm":
+		return newProcWasm(ctx, cfg)
+	case "window":
+		return newProcWindow(ctx, cfg)
 	default:
 		return nil, fmt.Errorf("process: new_batcher: type %q settings %+v: %v", cfg.Type, cfg.Settings, errors.ErrInvalidFactoryInput)
 	}
@@ -0,0 +1,245 @@
+//go:build !wasm
+
+package process
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"os"
+
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/base64"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/file"
+)
+
+// errProtobufInvalidMessage is returned when the protobuf processor is
+// configured with a message that is not a message type.
+var errProtobufInvalidMessage = fmt.Errorf("invalid message type")
+
+// protobuf processes data by converting it to and from Protocol Buffers
+// (https://protobuf.dev/). Messages are described by a FileDescriptorSet,
+// which can be created by running protoc with the --descriptor_set_out
+// and --include_imports flags.
+//
+// When handling objects, protobuf messages are stored in the object as base64
+// encoded strings.
+//
+// This processor supports the data and object handling patterns.
+type procProtobuf struct {
+	process
+	Options procProtobufOptions `json:"options"`
+
+	desc protoreflect.MessageDescriptor
+}
+
+type procProtobufOptions struct {
+	// Direction determines whether data is encoded or decoded.
+	//
+	// Must be one of:
+	//
+	// - to: encode an object to a protobuf message
+	//
+	// - from: decode a protobuf message to an object
+	Direction string `json:"direction"`
+	// Descriptor contains the location of a FileDescriptorSet that
+	// describes the message. This can be either a path on local disk,
+	// an HTTP(S) URL, or an AWS S3 URL.
+	Descriptor string `json:"descriptor"`
+	// Message is the fully qualified name of the message type
+	// (e.g., package.Message).
+	Message string `json:"message"`
+}
+
+// Create a new protobuf processor.
+func newProcProtobuf(ctx context.Context, cfg config.Config) (p procProtobuf, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procProtobuf{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procProtobuf{}, err
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	// error early if required options are missing
+	if p.Options.Descriptor == "" || p.Options.Message == "" {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	path, err := file.Get(ctx, p.Options.Descriptor)
+	defer os.Remove(path)
+
+	if err != nil {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: %v", err)
+	}
+
+	var fds descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(b, &fds); err != nil {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: descriptor %s: %v", p.Options.Descriptor, err)
+	}
+
+	files, err := protodesc.NewFiles(&fds)
+	if err != nil {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: descriptor %s: %v", p.Options.Descriptor, err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(p.Options.Message))
+	if err != nil {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: message %s: %v", p.Options.Message, err)
+	}
+
+	var ok bool
+	p.desc, ok = desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return procProtobuf{}, fmt.Errorf("process: protobuf: message %s: %v", p.Options.Message, errProtobufInvalidMessage)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procProtobuf) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procProtobuf) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procProtobuf) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procProtobuf) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key)
+
+		switch p.Options.Direction {
+		case "from":
+			b, err := base64.Decode([]byte(result.String()))
+			if err != nil {
+				return capsule, fmt.Errorf("process: protobuf: %v", err)
+			}
+
+			value, err := p.from(b)
+			if err != nil {
+				return capsule, fmt.Errorf("process: protobuf: %v", err)
+			}
+
+			if err := capsule.Set(p.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: protobuf: %v", err)
+			}
+		case "to":
+			value, err := p.to([]byte(result.Raw))
+			if err != nil {
+				return capsule, fmt.Errorf("process: protobuf: %v", err)
+			}
+
+			// encoding is explicit because protobuf messages are not
+			// guaranteed to contain binary data
+			if err := capsule.Set(p.SetKey, string(base64.Encode(value))); err != nil {
+				return capsule, fmt.Errorf("process: protobuf: %v", err)
+			}
+		default:
+			return capsule, fmt.Errorf("process: protobuf: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		var value []byte
+		var err error
+
+		switch p.Options.Direction {
+		case "from":
+			value, err = p.from(capsule.Data())
+		case "to":
+			value, err = p.to(capsule.Data())
+		default:
+			return capsule, fmt.Errorf("process: protobuf: direction %s: %v", p.Options.Direction, errInvalidDirection)
+		}
+
+		if err != nil {
+			return capsule, fmt.Errorf("process: protobuf: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: protobuf: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// from decodes a protobuf message into a JSON object.
+func (p procProtobuf) from(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(p.desc)
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	b, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// protojson does not produce stable output, so the object
+	// is compacted to remove any inserted whitespace
+	dst := &bytes.Buffer{}
+	if err := gojson.Compact(dst, b); err != nil {
+		return nil, err
+	}
+
+	return dst.Bytes(), nil
+}
+
+// to encodes a JSON object into a protobuf message.
+func (p procProtobuf) to(data []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(p.desc)
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	// deterministic output is required so that identical objects
+	// always produce identical messages
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
@@ -0,0 +1,216 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procProtobuf{}
+	_ Batcher = procProtobuf{}
+)
+
+// protobufDescriptor returns a FileDescriptorSet that contains this message:
+//
+//	syntax = "proto3";
+//	package substation.test;
+//
+//	message Event {
+//	  string name = 1;
+//	  int64 count = 2;
+//	  repeated string tags = 3;
+//	}
+func protobufDescriptor() *descriptorpb.FileDescriptorSet {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(num),
+			Type:     typ.Enum(),
+			Label:    label.Enum(),
+		}
+	}
+
+	return &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("event.proto"),
+				Package: proto.String("substation.test"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Event"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+							field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL),
+							field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var protobufTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON from",
+		config.Config{
+			Type: "protobuf",
+			Settings: map[string]interface{}{
+				"key":     "proto",
+				"set_key": "proto",
+				"options": map[string]interface{}{
+					"direction": "from",
+					"message":   "substation.test.Event",
+				},
+			},
+		},
+		[]byte(`{"proto":"CgNmb28QAxoDYmFyGgNiYXo="}`),
+		[]byte(`{"proto":{"name":"foo","count":"3","tags":["bar","baz"]}}`),
+		nil,
+	},
+	{
+		"JSON to",
+		config.Config{
+			Type: "protobuf",
+			Settings: map[string]interface{}{
+				"key":     "proto",
+				"set_key": "proto",
+				"options": map[string]interface{}{
+					"direction": "to",
+					"message":   "substation.test.Event",
+				},
+			},
+		},
+		[]byte(`{"proto":{"name":"foo","count":3,"tags":["bar","baz"]}}`),
+		[]byte(`{"proto":"CgNmb28QAxoDYmFyGgNiYXo="}`),
+		nil,
+	},
+	{
+		"data from",
+		config.Config{
+			Type: "protobuf",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "from",
+					"message":   "substation.test.Event",
+				},
+			},
+		},
+		[]byte("\x0a\x03foo\x10\x03\x1a\x03bar\x1a\x03baz"),
+		[]byte(`{"name":"foo","count":"3","tags":["bar","baz"]}`),
+		nil,
+	},
+	{
+		"data to",
+		config.Config{
+			Type: "protobuf",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"direction": "to",
+					"message":   "substation.test.Event",
+				},
+			},
+		},
+		[]byte(`{"name":"foo","count":"3","tags":["bar","baz"]}`),
+		[]byte("\x0a\x03foo\x10\x03\x1a\x03bar\x1a\x03baz"),
+		nil,
+	},
+}
+
+func TestProtobuf(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	descriptor, err := protobufTestFile(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range protobufTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			test.cfg.Settings["options"].(map[string]interface{})["descriptor"] = descriptor
+			proc, err := newProcProtobuf(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+// protobufTestFile writes the test FileDescriptorSet to a temporary file and
+// returns the name of the file.
+func protobufTestFile(t testing.TB) (string, error) {
+	b, err := proto.Marshal(protobufDescriptor())
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "descriptor")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(b); err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+func benchmarkProtobuf(b *testing.B, applier procProtobuf, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkProtobuf(b *testing.B) {
+	capsule := config.NewCapsule()
+
+	descriptor, err := protobufTestFile(b)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, test := range protobufTests {
+		test.cfg.Settings["options"].(map[string]interface{})["descriptor"] = descriptor
+		proc, err := newProcProtobuf(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkProtobuf(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procProtobuf struct {
+	process
+	Options procProtobufOptions `json:"options"`
+}
+
+type procProtobufOptions struct{}
+
+func newProcProtobuf(ctx context.Context, cfg config.Config) (p procProtobuf, err error) {
+	return procProtobuf{}, fmt.Errorf("process: protobuf: %v", syscall.ENOSYS)
+}
+
+func (p procProtobuf) String() string {
+	return toString(p)
+}
+
+func (p procProtobuf) Close(ctx context.Context) error {
+	return fmt.Errorf("close: protobuf: %v", syscall.ENOSYS)
+}
+
+func (p procProtobuf) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procProtobuf) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: protobuf: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,415 @@
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// redactDetector identifies sensitive values in data. If validate is not
+// nil, then it is used to remove false positives from matches. If shrink is
+// not nil, then it returns shorter candidates (as offsets from the start of
+// the match) that are validated if a match fails validation.
+type redactDetector struct {
+	name     string
+	re       *regexp.Regexp
+	validate func(string) bool
+	shrink   func(string) []int
+}
+
+// redactDetectors are the built-in detectors. Detectors are ordered by
+// priority; if the matches of multiple detectors overlap, then the match from
+// the first detector is kept.
+var redactDetectors = []redactDetector{
+	{
+		name: "jwt",
+		re:   regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]+\.eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	},
+	{
+		name: "aws_access_key",
+		re:   regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA|AGPA|AIDA|AIPA|ANPA|ANVA|AROA|APKA)[A-Z0-9]{16}\b`),
+	},
+	{
+		name: "email",
+		re:   regexp.MustCompile(`\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`),
+	},
+	{
+		name:     "credit_card",
+		re:       regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		validate: redactLuhn,
+		shrink:   redactDigitPrefixes,
+	},
+	{
+		name:     "ssn",
+		re:       regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		validate: redactSSN,
+	},
+	{
+		name: "phone",
+		re:   regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?(?:\(\d{3}\)|\b\d{3})[ .\-]?\d{3}[ .\-]?\d{4}\b`),
+	},
+}
+
+// redact processes data by detecting and redacting personally identifiable
+// information (PII) and secrets. Built-in detectors find these values:
+//
+// - credit_card: payment card numbers that pass the Luhn check
+//
+// - ssn: US Social Security numbers
+//
+// - email: email addresses
+//
+// - phone: phone numbers
+//
+// - aws_access_key: AWS access key IDs
+//
+// - jwt: JSON Web Tokens
+//
+// Custom detectors can be created using regular expressions. If a report key
+// is configured, then the names of the detectors that found values (but not
+// the values) are put into the report key as an array.
+//
+// This processor supports the data and object handling patterns.
+type procRedact struct {
+	process
+	Options procRedactOptions `json:"options"`
+
+	detectors []redactDetector
+}
+
+type procRedactOptions struct {
+	// Detectors are the names of built-in detectors that are applied to
+	// the data.
+	//
+	// This is optional and defaults to all built-in detectors if no custom
+	// detectors are configured.
+	Detectors []string `json:"detectors"`
+	// Custom are detectors that find values using regular expressions.
+	//
+	// This is optional and defaults to an empty list.
+	Custom []procRedactCustom `json:"custom"`
+	// Action determines how detected values are redacted.
+	//
+	// Must be one of:
+	//
+	// - mask: every character in the value is replaced by Character
+	//
+	// - replace: the value is replaced by Placeholder
+	//
+	// - hash: the value is replaced by its SHA-256 hash (use the tokenize
+	// processor if values must not be reversible with a dictionary attack)
+	//
+	// - drop: the key is deleted from the object if any value is detected
+	// (only supports the object handling pattern and does not use set_key)
+	Action string `json:"action"`
+	// Character is used to mask values.
+	//
+	// This is optional and defaults to "*".
+	Character string `json:"character"`
+	// Placeholder is used to replace values.
+	//
+	// This is optional and defaults to "[REDACTED]".
+	Placeholder string `json:"placeholder"`
+	// ReportKey is where the names of the detectors that found values are
+	// put. This is commonly a metadata key (e.g., !metadata redact).
+	//
+	// This is optional and defaults to not reporting detectors.
+	ReportKey string `json:"report_key"`
+}
+
+type procRedactCustom struct {
+	// Name identifies the detector in reports.
+	Name string `json:"name"`
+	// Expression is the regular expression used to find values.
+	Expression string `json:"expression"`
+}
+
+// Create a new redact processor.
+func newProcRedact(ctx context.Context, cfg config.Config) (p procRedact, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procRedact{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procRedact{}, err
+	}
+
+	//  validate option.action
+	if !slices.Contains(
+		[]string{
+			"mask",
+			"replace",
+			"hash",
+			"drop",
+		},
+		p.Options.Action) {
+		return procRedact{}, fmt.Errorf("process: redact: action %q: %v", p.Options.Action, errors.ErrInvalidOption)
+	}
+
+	// validate data processing pattern, the drop action only deletes keys
+	// so it does not use set_key
+	if p.Options.Action == "drop" {
+		if p.Key == "" {
+			return procRedact{}, fmt.Errorf("process: redact: action %s: %v", p.Options.Action, errInvalidDataPattern)
+		}
+	} else if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procRedact{}, fmt.Errorf("process: redact: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	if p.Options.Character == "" {
+		p.Options.Character = "*"
+	}
+
+	if p.Options.Placeholder == "" {
+		p.Options.Placeholder = "[REDACTED]"
+	}
+
+	if len(p.Options.Detectors) == 0 && len(p.Options.Custom) == 0 {
+		p.detectors = redactDetectors
+	}
+
+	for _, name := range p.Options.Detectors {
+		i := slices.IndexFunc(redactDetectors, func(d redactDetector) bool {
+			return d.name == name
+		})
+		if i == -1 {
+			return procRedact{}, fmt.Errorf("process: redact: detector %q: %v", name, errors.ErrInvalidOption)
+		}
+
+		p.detectors = append(p.detectors, redactDetectors[i])
+	}
+
+	for _, c := range p.Options.Custom {
+		if c.Name == "" || c.Expression == "" {
+			return procRedact{}, fmt.Errorf("process: redact: custom %+v: %v", c, errors.ErrMissingRequiredOption)
+		}
+
+		re, err := regexp.Compile(c.Expression)
+		if err != nil {
+			return procRedact{}, fmt.Errorf("process: redact: custom %s: %v", c.Name, err)
+		}
+
+		p.detectors = append(p.detectors, redactDetector{name: c.Name, re: re})
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procRedact) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procRedact) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procRedact) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procRedact) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && (p.SetKey != "" || p.Options.Action == "drop") {
+		result := capsule.Get(p.Key)
+		if !result.Exists() {
+			return capsule, nil
+		}
+
+		value, found := p.redact(result.String())
+		if p.Options.Action == "drop" && len(found) > 0 {
+			if err := capsule.Delete(p.Key); err != nil {
+				return capsule, fmt.Errorf("process: redact: %v", err)
+			}
+		} else if p.Options.Action != "drop" {
+			if err := capsule.Set(p.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: redact: %v", err)
+			}
+		}
+
+		if err := p.report(&capsule, found); err != nil {
+			return capsule, fmt.Errorf("process: redact: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, found := p.redact(string(capsule.Data()))
+		capsule.SetData([]byte(value))
+
+		if err := p.report(&capsule, found); err != nil {
+			return capsule, fmt.Errorf("process: redact: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: redact: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// report puts the sorted names of detectors that found values into the
+// report key.
+func (p procRedact) report(capsule *config.Capsule, found map[string]struct{}) error {
+	if p.Options.ReportKey == "" || len(found) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return capsule.Set(p.Options.ReportKey, names)
+}
+
+type redactMatch struct {
+	start, end int
+	detector   string
+}
+
+// redact returns the string with all detected values redacted and the set of
+// detectors that found values. Matches are found in the original string so
+// that redacted values (e.g., hashes) cannot be matched by other detectors.
+func (p procRedact) redact(s string) (string, map[string]struct{}) {
+	var matches []redactMatch
+	for _, d := range p.detectors {
+		for _, loc := range d.re.FindAllStringIndex(s, -1) {
+			end, ok := d.match(s[loc[0]:loc[1]])
+			if !ok {
+				continue
+			}
+
+			matches = append(matches, redactMatch{loc[0], loc[0] + end, d.name})
+		}
+	}
+
+	found := make(map[string]struct{})
+	if len(matches) == 0 {
+		return s, found
+	}
+
+	// the stable sort keeps matches from higher priority detectors
+	// in front of overlapping matches from other detectors
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].start < matches[j].start
+	})
+
+	var b strings.Builder
+	var pos int
+	for _, m := range matches {
+		// overlapping matches are skipped
+		if m.start < pos {
+			continue
+		}
+
+		found[m.detector] = struct{}{}
+		b.WriteString(s[pos:m.start])
+		b.WriteString(p.replace(s[m.start:m.end]))
+		pos = m.end
+	}
+	b.WriteString(s[pos:])
+
+	return b.String(), found
+}
+
+// match returns the end of the longest valid match in the string and
+// whether a valid match was found.
+func (d redactDetector) match(s string) (int, bool) {
+	if d.validate == nil || d.validate(s) {
+		return len(s), true
+	}
+
+	if d.shrink == nil {
+		return 0, false
+	}
+
+	for _, end := range d.shrink(s) {
+		if d.validate(s[:end]) {
+			return end, true
+		}
+	}
+
+	return 0, false
+}
+
+// replace returns the redacted form of a value.
+func (p procRedact) replace(s string) string {
+	switch p.Options.Action {
+	case "mask":
+		return strings.Repeat(p.Options.Character, len([]rune(s)))
+	case "hash":
+		return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+	default:
+		return p.Options.Placeholder
+	}
+}
+
+// redactLuhn returns true if the digits in the string pass the Luhn check
+// (https://en.wikipedia.org/wiki/Luhn_algorithm).
+func redactLuhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+
+		sum += d
+		n++
+	}
+
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// redactDigitPrefixes returns the ends of prefixes of the string that end
+// with a digit, from longest to shortest. Regular expressions match as many
+// digits as possible, so this is used to find valid numbers that are
+// followed by other digits.
+func redactDigitPrefixes(s string) []int {
+	var ends []int
+	for i := len(s) - 1; i > 0; i-- {
+		if s[i-1] >= '0' && s[i-1] <= '9' {
+			ends = append(ends, i)
+		}
+	}
+
+	return ends
+}
+
+// redactSSN returns true if the string is a valid Social Security number.
+// Numbers with an area of 000, 666, or 900-999, a group of 00, or a serial
+// of 0000 are never issued.
+func redactSSN(s string) bool {
+	area, group, serial := s[0:3], s[4:6], s[7:11]
+	if area == "000" || area == "666" || area[0] == '9' {
+		return false
+	}
+
+	return group != "00" && serial != "0000"
+}
@@ -0,0 +1,318 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	gojson "encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
+)
+
+// sample processes data by keeping a subset of it and dropping the rest.
+// Data that does not match the processor's condition is always kept, so
+// conditions can be used to select which data is sampled (e.g., sample DNS
+// logs, but keep all errors).
+//
+// Samples are selected using either of these methods:
+//
+// - hash: data is consistently kept or dropped based on the hash of a
+// value, so all data that shares the same value is either kept or dropped
+//
+// - rate_limit: data is kept until a token bucket for a value is empty,
+// which limits the number of events per second for each value. Buckets are
+// refilled over time and persist between batches, so the limit applies to
+// all data seen by the processor (or by all processors that share a KV
+// store), not to each batch.
+//
+// If a set key is configured, then the sample rate (the probability that
+// data was kept) is put into the set key. This is commonly a metadata key
+// (e.g., !metadata sample_rate) and can be used to estimate the original
+// volume of data. When using rate limits, the sample rate is calculated
+// separately for each batch from the number of events that were seen and
+// kept for each value in the batch.
+//
+// This processor supports the data and object handling patterns.
+type procSample struct {
+	process
+	Options procSampleOptions `json:"options"`
+
+	// buckets stores token buckets for rate limits.
+	buckets kv.Storer
+	mu      *sync.Mutex
+}
+
+type procSampleOptions struct {
+	// Type determines the method used to sample data.
+	//
+	// Must be one of:
+	//
+	// - hash
+	//
+	// - rate_limit
+	Type string `json:"type"`
+	// Rate is the fraction (between 0 and 1) of values that are kept when
+	// using hash sampling.
+	Rate float64 `json:"rate"`
+	// Limit is the number of events per second that are kept for each
+	// value when using rate limits.
+	Limit float64 `json:"limit"`
+	// Burst is the maximum number of events that are kept for each value
+	// before the rate limit is applied.
+	//
+	// This is optional and defaults to the limit.
+	Burst float64 `json:"burst"`
+	// Capacity limits the number of token buckets that are stored in
+	// memory. Buckets are evicted using least recently used (LRU)
+	// eviction. This cannot be used with KVOptions; the capacity of other
+	// KV stores is configured in KVOptions.
+	//
+	// This is optional and defaults to 1024 buckets.
+	Capacity int `json:"capacity"`
+	// KVOptions determine the type of KV store used to share token buckets
+	// between processors. Refer to internal/kv for more information.
+	//
+	// This is optional and defaults to storing buckets in memory.
+	KVOptions config.Config `json:"kv_options"`
+}
+
+// sampleBucket is a token bucket. Buckets are stored in KV stores as JSON.
+type sampleBucket struct {
+	Tokens  float64 `json:"tokens"`
+	Updated int64   `json:"updated"`
+}
+
+// Create a new sample processor.
+func newProcSample(ctx context.Context, cfg config.Config) (p procSample, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procSample{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procSample{}, err
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"hash",
+			"rate_limit",
+		},
+		p.Options.Type) {
+		return procSample{}, fmt.Errorf("process: sample: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Type == "hash" && (p.Options.Rate <= 0 || p.Options.Rate > 1) {
+		return procSample{}, fmt.Errorf("process: sample: rate %v: %v", p.Options.Rate, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Type != "rate_limit" {
+		return p, nil
+	}
+
+	if p.Options.Limit <= 0 {
+		return procSample{}, fmt.Errorf("process: sample: limit %v: %v", p.Options.Limit, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Burst == 0 {
+		p.Options.Burst = p.Options.Limit
+	}
+
+	if p.Options.KVOptions.Type != "" && p.Options.Capacity != 0 {
+		return procSample{}, fmt.Errorf("process: sample: capacity %d kv_options %s: %v", p.Options.Capacity, p.Options.KVOptions.Type, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Capacity == 0 {
+		p.Options.Capacity = 1024
+	}
+
+	if p.Options.KVOptions.Type != "" {
+		p.buckets, err = kv.Get(p.Options.KVOptions)
+	} else {
+		p.buckets, err = kv.New(config.Config{
+			Type: "memory",
+			Settings: map[string]interface{}{
+				"capacity": p.Options.Capacity,
+			},
+		})
+	}
+
+	if err != nil {
+		return procSample{}, fmt.Errorf("process: sample: %v", err)
+	}
+
+	// lazy load the KV store
+	if !p.buckets.IsEnabled() {
+		if err := p.buckets.Setup(ctx); err != nil {
+			return procSample{}, fmt.Errorf("process: sample: %v", err)
+		}
+	}
+
+	p.mu = &sync.Mutex{}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procSample) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procSample) Close(context.Context) error {
+	if p.IgnoreClose || p.buckets == nil {
+		return nil
+	}
+
+	if p.buckets.IsEnabled() {
+		if err := p.buckets.Close(); err != nil {
+			return fmt.Errorf("close: sample: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procSample) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	newCapsules := newBatch(&capsules)
+
+	// the sample rate of rate limits depends on the number of events
+	// that are seen for each value, so they are counted before the rate
+	// is put into the capsules
+	seen := make(map[string]int)
+	kept := make(map[string]int)
+	var keys []string
+	var sampled []bool
+
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: sample: %v", err)
+		}
+
+		if !ok {
+			newCapsules = append(newCapsules, capsule)
+			keys = append(keys, "")
+			sampled = append(sampled, false)
+			continue
+		}
+
+		var value string
+		if p.Key != "" {
+			value = capsule.Get(p.Key).String()
+		} else {
+			value = string(capsule.Data())
+		}
+
+		var keep bool
+		switch p.Options.Type {
+		case "hash":
+			keep = sampleHash(value) < p.Options.Rate
+		case "rate_limit":
+			keep, err = p.take(ctx, value)
+			if err != nil {
+				return nil, fmt.Errorf("process: sample: %v", err)
+			}
+		}
+
+		seen[value]++
+		if !keep {
+			continue
+		}
+
+		kept[value]++
+		newCapsules = append(newCapsules, capsule)
+		keys = append(keys, value)
+		sampled = append(sampled, true)
+	}
+
+	if p.SetKey == "" {
+		return newCapsules, nil
+	}
+
+	for i := range newCapsules {
+		// capsules that did not match the condition were not sampled
+		rate := 1.0
+		if sampled[i] {
+			switch p.Options.Type {
+			case "hash":
+				rate = p.Options.Rate
+			case "rate_limit":
+				rate = float64(kept[keys[i]]) / float64(seen[keys[i]])
+			}
+		}
+
+		if err := newCapsules[i].Set(p.SetKey, rate); err != nil {
+			return nil, fmt.Errorf("process: sample: %v", err)
+		}
+	}
+
+	return newCapsules, nil
+}
+
+// take removes a token from the bucket for a value and returns true if a
+// token was available.
+func (p procSample) take(ctx context.Context, value string) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	bucket := sampleBucket{Tokens: p.Options.Burst, Updated: now.UnixNano()}
+
+	v, err := p.buckets.Get(ctx, value)
+	if err != nil {
+		return false, err
+	}
+
+	if s, ok := v.(string); ok {
+		if err := gojson.Unmarshal([]byte(s), &bucket); err != nil {
+			return false, err
+		}
+
+		// tokens are refilled based on the time since the bucket was
+		// last updated
+		elapsed := time.Duration(now.UnixNano() - bucket.Updated).Seconds()
+		bucket.Tokens = math.Min(p.Options.Burst, bucket.Tokens+elapsed*p.Options.Limit)
+		bucket.Updated = now.UnixNano()
+	}
+
+	keep := bucket.Tokens >= 1
+	if keep {
+		bucket.Tokens--
+	}
+
+	b, err := gojson.Marshal(bucket)
+	if err != nil {
+		return false, err
+	}
+
+	// buckets expire after they would have been refilled
+	refill := time.Duration(p.Options.Burst/p.Options.Limit*float64(time.Second)) + time.Second
+	if err := p.buckets.SetWithTTL(ctx, value, string(b), now.Add(refill).Unix()); err != nil {
+		return false, err
+	}
+
+	return keep, nil
+}
+
+// sampleHash returns a number between 0 and 1 that is derived from the hash
+// of a value.
+func sampleHash(value string) float64 {
+	sum := sha256.Sum256([]byte(value))
+
+	return float64(binary.BigEndian.Uint64(sum[:8])) / float64(math.MaxUint64)
+}
@@ -0,0 +1,227 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var _ Batcher = procSample{}
+
+var sampleTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+	err      error
+}{
+	{
+		"hash",
+		config.Config{
+			Type: "sample",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "rate",
+				"options": map[string]interface{}{
+					"type": "hash",
+					"rate": 0.5,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"ip":"a"}`),
+			[]byte(`{"ip":"b"}`),
+			[]byte(`{"ip":"a"}`),
+			[]byte(`{"ip":"c"}`),
+			[]byte(`{"ip":"g"}`),
+			[]byte(`{"ip":"b"}`),
+		},
+		[][]byte{
+			[]byte(`{"ip":"b","rate":0.5}`),
+			[]byte(`{"ip":"c","rate":0.5}`),
+			[]byte(`{"ip":"b","rate":0.5}`),
+		},
+		nil,
+	},
+	{
+		"hash with condition",
+		config.Config{
+			Type: "sample",
+			Settings: map[string]interface{}{
+				"key":     "ip",
+				"set_key": "rate",
+				"condition": map[string]interface{}{
+					"operator": "all",
+					"inspectors": []config.Config{
+						{
+							Type: "strings",
+							Settings: map[string]interface{}{
+								"key":    "error",
+								"negate": true,
+								"options": map[string]interface{}{
+									"type":       "equals",
+									"expression": "true",
+								},
+							},
+						},
+					},
+				},
+				"options": map[string]interface{}{
+					"type": "hash",
+					"rate": 0.01,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"ip":"a","error":false}`),
+			[]byte(`{"ip":"a","error":true}`),
+		},
+		[][]byte{
+			[]byte(`{"ip":"a","error":true,"rate":1}`),
+		},
+		nil,
+	},
+	{
+		"rate_limit",
+		config.Config{
+			Type: "sample",
+			Settings: map[string]interface{}{
+				"key":     "host",
+				"set_key": "!metadata rate",
+				"options": map[string]interface{}{
+					"type":  "rate_limit",
+					"limit": 1,
+					"burst": 2,
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"host":"foo"}`),
+			[]byte(`{"host":"foo"}`),
+			[]byte(`{"host":"foo"}`),
+			[]byte(`{"host":"bar"}`),
+			[]byte(`{"host":"foo"}`),
+		},
+		[][]byte{
+			[]byte(`{"host":"foo"}`),
+			[]byte(`{"host":"foo"}`),
+			[]byte(`{"host":"bar"}`),
+		},
+		nil,
+	},
+}
+
+func TestSample(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range sampleTests {
+		t.Run(test.name, func(t *testing.T) {
+			var capsules []config.Capsule
+			for _, t := range test.test {
+				capsule.SetData(t)
+				capsules = append(capsules, capsule)
+			}
+
+			proc, err := newProcSample(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Batch(ctx, capsules...)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d, got %d", len(test.expected), len(result))
+			}
+
+			for i, res := range result {
+				if !bytes.Equal(res.Data(), test.expected[i]) {
+					t.Errorf("expected %s, got %s", test.expected[i], res.Data())
+				}
+			}
+		})
+	}
+}
+
+func TestSampleRateLimitMetadata(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcSample(ctx, sampleTests[2].cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capsules []config.Capsule
+	for _, data := range sampleTests[2].test {
+		capsule := config.NewCapsule()
+		capsule.SetData(data)
+		capsules = append(capsules, capsule)
+	}
+
+	result, err := proc.Batch(ctx, capsules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two of four foo events and one of one bar events were kept
+	expected := []string{"0.5", "0.5", "1"}
+	for i, res := range result {
+		if rate := res.Get("!metadata rate").String(); rate != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], rate)
+		}
+	}
+}
+
+func TestSampleCapacityKVOptions(t *testing.T) {
+	_, err := newProcSample(context.TODO(), config.Config{
+		Type: "sample",
+		Settings: map[string]interface{}{
+			"key": "host",
+			"options": map[string]interface{}{
+				"type":     "rate_limit",
+				"limit":    1,
+				"capacity": 10,
+				"kv_options": map[string]interface{}{
+					"type": "memory",
+				},
+			},
+		},
+	})
+	if err == nil {
+		t.Error("expected error for capacity with kv_options")
+	}
+}
+
+func benchmarkSample(b *testing.B, batcher procSample, capsules []config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = batcher.Batch(ctx, capsules...)
+	}
+}
+
+func BenchmarkSample(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range sampleTests {
+		var capsules []config.Capsule
+		for _, t := range test.test {
+			capsule.SetData(t)
+			capsules = append(capsules, capsule)
+		}
+
+		proc, err := newProcSample(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkSample(b, proc, capsules)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procSample struct {
+	process
+	Options procSampleOptions `json:"options"`
+}
+
+type procSampleOptions struct{}
+
+func newProcSample(ctx context.Context, cfg config.Config) (p procSample, err error) {
+	return procSample{}, fmt.Errorf("process: sample: %v", syscall.ENOSYS)
+}
+
+func (p procSample) String() string {
+	return toString(p)
+}
+
+func (p procSample) Close(ctx context.Context) error {
+	return fmt.Errorf("close: sample: %v", syscall.ENOSYS)
+}
+
+func (p procSample) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procSample) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: sample: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,373 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.starlark.net/lib/json"
+	"go.starlark.net/starlark"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// errScriptBatchLength is returned when the script processor is used as an
+// Applier in batch mode and the script does not return exactly one capsule.
+var errScriptBatchLength = fmt.Errorf("script must return one capsule")
+
+// script processes data by running a Starlark (https://github.com/bazelbuild/starlark)
+// script. Scripts must define a function named process that accepts a capsule
+// and modifies it:
+//
+//	def process(capsule):
+//	  if capsule.get("foo") == "bar":
+//	    capsule.set("baz", capsule.get("qux") * 2)
+//	    capsule.delete("qux")
+//
+// If batch mode is enabled, then the function accepts a list of capsules and
+// returns a list of capsules, which allows scripts to emit zero or many
+// capsules (new capsules are created with new_capsule()).
+//
+// Capsules have these methods:
+//
+// - get(key): returns a value from data or metadata (e.g., get("!metadata foo")),
+// or None if the value does not exist
+//
+// - set(key, value): inserts a value into data or metadata
+//
+// - delete(key): removes a value from data or metadata
+//
+// - data(): returns the data as a string
+//
+// - set_data(value): replaces the data with a string
+//
+// Scripts are sandboxed (they cannot access files or the network) and can
+// use the json module. The execution of each script is limited by the number
+// of steps (an abstract measure of CPU usage) and time.
+//
+// This processor supports the data and object handling patterns.
+type procScript struct {
+	process
+	Options procScriptOptions `json:"options"`
+
+	fn starlark.Value
+}
+
+type procScriptOptions struct {
+	// Script is the Starlark source code of the script.
+	Script string `json:"script"`
+	// Batch determines if the script processes a batch of capsules.
+	//
+	// This is optional and defaults to false (the script processes one
+	// capsule at a time).
+	Batch bool `json:"batch"`
+	// MaxSteps is the maximum number of steps that a script can execute
+	// each time it is called.
+	//
+	// This is optional and defaults to 1000000 steps.
+	MaxSteps int `json:"max_steps"`
+	// Timeout is the amount of time to wait (in milliseconds) for the script
+	// to complete each time it is called.
+	//
+	// This is optional and defaults to 1000 milliseconds (1 second).
+	Timeout int `json:"timeout"`
+}
+
+// scriptPredeclared are the names that are available to all scripts.
+var scriptPredeclared = starlark.StringDict{
+	"json":        json.Module,
+	"new_capsule": starlark.NewBuiltin("new_capsule", scriptNewCapsule),
+}
+
+// Create a new script processor.
+func newProcScript(ctx context.Context, cfg config.Config) (p procScript, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procScript{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procScript{}, err
+	}
+
+	// error early if required options are missing
+	if p.Options.Script == "" {
+		return procScript{}, fmt.Errorf("process: script: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.MaxSteps == 0 {
+		p.Options.MaxSteps = 1000000
+	}
+
+	if p.Options.Timeout == 0 {
+		p.Options.Timeout = 1000
+	}
+
+	thread := p.thread()
+	timer := time.AfterFunc(time.Duration(p.Options.Timeout)*time.Millisecond, func() {
+		thread.Cancel("timeout")
+	})
+	defer timer.Stop()
+
+	globals, err := starlark.ExecFile(thread, "script", p.Options.Script, scriptPredeclared)
+	if err != nil {
+		return procScript{}, fmt.Errorf("process: script: %v", err)
+	}
+
+	// globals are frozen so that they can be safely used by concurrent
+	// threads
+	globals.Freeze()
+
+	fn, ok := globals["process"].(starlark.Callable)
+	if !ok {
+		return procScript{}, fmt.Errorf("process: script: process function: %v", errors.ErrMissingRequiredOption)
+	}
+	p.fn = fn
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procScript) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procScript) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procScript) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	if !p.Options.Batch {
+		return batchApply(ctx, capsules, p, p.operator)
+	}
+
+	var list []starlark.Value
+	newCapsules := newBatch(&capsules)
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: script: %v", err)
+		}
+
+		if !ok {
+			newCapsules = append(newCapsules, capsule)
+			continue
+		}
+
+		c := capsule
+		list = append(list, &scriptCapsule{capsule: &c})
+	}
+
+	// the script is not called if no capsules matched the condition
+	if len(list) == 0 {
+		return newCapsules, nil
+	}
+
+	results, err := p.batch(ctx, list)
+	if err != nil {
+		return nil, fmt.Errorf("process: script: %v", err)
+	}
+
+	return append(newCapsules, results...), nil
+}
+
+// Apply processes a capsule with the processor.
+func (p procScript) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	if p.Options.Batch {
+		results, err := p.batch(ctx, []starlark.Value{&scriptCapsule{capsule: &capsule}})
+		if err != nil {
+			return capsule, fmt.Errorf("process: script: %v", err)
+		}
+
+		if len(results) != 1 {
+			return capsule, fmt.Errorf("process: script: returned %d capsules: %v", len(results), errScriptBatchLength)
+		}
+
+		return results[0], nil
+	}
+
+	if _, err := p.call(ctx, &scriptCapsule{capsule: &capsule}); err != nil {
+		return capsule, fmt.Errorf("process: script: %v", err)
+	}
+
+	return capsule, nil
+}
+
+// batch calls the script with a list of capsules and returns the capsules
+// that were returned by the script.
+func (p procScript) batch(ctx context.Context, capsules []starlark.Value) ([]config.Capsule, error) {
+	value, err := p.call(ctx, starlark.NewList(capsules))
+	if err != nil {
+		return nil, err
+	}
+
+	iter, ok := value.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("process function returned %s, want list", value.Type())
+	}
+
+	var results []config.Capsule
+	it := iter.Iterate()
+	defer it.Done()
+
+	var v starlark.Value
+	for it.Next(&v) {
+		c, ok := v.(*scriptCapsule)
+		if !ok {
+			return nil, fmt.Errorf("process function returned %s, want capsule", v.Type())
+		}
+
+		results = append(results, *c.capsule)
+	}
+
+	return results, nil
+}
+
+// call calls the script's process function with limits on the number of
+// steps and time.
+func (p procScript) call(ctx context.Context, arg starlark.Value) (starlark.Value, error) {
+	thread := p.thread()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Options.Timeout)*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			thread.Cancel(ctx.Err().Error())
+		case <-done:
+		}
+	}()
+
+	return starlark.Call(thread, p.fn, starlark.Tuple{arg}, nil)
+}
+
+// thread returns a new thread that is limited by the maximum number of
+// steps. Scripts cannot load modules.
+func (p procScript) thread() *starlark.Thread {
+	thread := &starlark.Thread{Name: "script"}
+	thread.SetMaxExecutionSteps(uint64(p.Options.MaxSteps))
+
+	return thread
+}
+
+// scriptCapsule is the Starlark representation of a capsule.
+type scriptCapsule struct {
+	capsule *config.Capsule
+}
+
+var (
+	_ starlark.Value    = &scriptCapsule{}
+	_ starlark.HasAttrs = &scriptCapsule{}
+)
+
+func (c *scriptCapsule) String() string        { return fmt.Sprintf("capsule(%s)", c.capsule.Data()) }
+func (c *scriptCapsule) Type() string          { return "capsule" }
+func (c *scriptCapsule) Freeze()               {}
+func (c *scriptCapsule) Truth() starlark.Bool  { return starlark.True }
+func (c *scriptCapsule) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: capsule") }
+
+var scriptCapsuleMethods = map[string]*starlark.Builtin{
+	"get":      starlark.NewBuiltin("get", scriptCapsuleGet),
+	"set":      starlark.NewBuiltin("set", scriptCapsuleSet),
+	"delete":   starlark.NewBuiltin("delete", scriptCapsuleDelete),
+	"data":     starlark.NewBuiltin("data", scriptCapsuleData),
+	"set_data": starlark.NewBuiltin("set_data", scriptCapsuleSetData),
+}
+
+func (c *scriptCapsule) Attr(name string) (starlark.Value, error) {
+	if m, ok := scriptCapsuleMethods[name]; ok {
+		return m.BindReceiver(c), nil
+	}
+
+	return nil, nil
+}
+
+func (c *scriptCapsule) AttrNames() []string {
+	return []string{"data", "delete", "get", "set", "set_data"}
+}
+
+func scriptCapsuleGet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key); err != nil {
+		return nil, err
+	}
+
+	res := b.Receiver().(*scriptCapsule).capsule.Get(key)
+	if !res.Exists() {
+		return starlark.None, nil
+	}
+
+	return starlark.Call(thread, json.Module.Members["decode"], starlark.Tuple{starlark.String(res.Raw)}, nil)
+}
+
+func scriptCapsuleSet(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	var value starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &key, &value); err != nil {
+		return nil, err
+	}
+
+	raw, err := starlark.Call(thread, json.Module.Members["encode"], starlark.Tuple{value}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.Receiver().(*scriptCapsule).capsule.SetRaw(key, string(raw.(starlark.String))); err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+func scriptCapsuleDelete(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key); err != nil {
+		return nil, err
+	}
+
+	if err := b.Receiver().(*scriptCapsule).capsule.Delete(key); err != nil {
+		return nil, fmt.Errorf("%s: %v", b.Name(), err)
+	}
+
+	return starlark.None, nil
+}
+
+func scriptCapsuleData(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+
+	return starlark.String(b.Receiver().(*scriptCapsule).capsule.Data()), nil
+}
+
+func scriptCapsuleSetData(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var data string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &data); err != nil {
+		return nil, err
+	}
+
+	b.Receiver().(*scriptCapsule).capsule.SetData([]byte(data))
+	return starlark.None, nil
+}
+
+// scriptNewCapsule returns a new capsule with optional data.
+func scriptNewCapsule(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var data string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "data?", &data); err != nil {
+		return nil, err
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(data))
+
+	return &scriptCapsule{capsule: &capsule}, nil
+}
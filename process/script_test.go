@@ -0,0 +1,251 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procScript{}
+	_ Batcher = procScript{}
+)
+
+var scriptTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"set",
+		config.Config{
+			Type: "script",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"script": `
+def process(capsule):
+  if capsule.get("foo") == "bar":
+    capsule.set("baz", capsule.get("qux") * 2)
+    capsule.delete("qux")
+`,
+				},
+			},
+		},
+		[]byte(`{"foo":"bar","qux":10}`),
+		[]byte(`{"foo":"bar","baz":20}`),
+		nil,
+	},
+	{
+		"objects",
+		config.Config{
+			Type: "script",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"script": `
+def process(capsule):
+  tags = {t["key"]: t["value"] for t in capsule.get("tags") or []}
+  capsule.set("tags", tags)
+`,
+				},
+			},
+		},
+		[]byte(`{"tags":[{"key":"env","value":"prod"},{"key":"team","value":"sec"}]}`),
+		[]byte(`{"tags":{"env":"prod","team":"sec"}}`),
+		nil,
+	},
+	{
+		"data",
+		config.Config{
+			Type: "script",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"script": `
+def process(capsule):
+  capsule.set_data(capsule.data().upper())
+`,
+				},
+			},
+		},
+		[]byte(`foo`),
+		[]byte(`FOO`),
+		nil,
+	},
+	{
+		"metadata",
+		config.Config{
+			Type: "script",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"script": `
+def process(capsule):
+  capsule.set("!metadata foo", capsule.get("foo"))
+  capsule.set("bar", capsule.get("!metadata foo"))
+`,
+				},
+			},
+		},
+		[]byte(`{"foo":{"baz":true}}`),
+		[]byte(`{"foo":{"baz":true},"bar":{"baz":true}}`),
+		nil,
+	},
+}
+
+func TestScript(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range scriptTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcScript(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestScriptBatch(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcScript(ctx, config.Config{
+		Type: "script",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"batch": true,
+				"script": `
+def process(capsules):
+  results = []
+  for c in capsules:
+    if c.get("drop"):
+      continue
+
+    for r in c.get("records"):
+      results.append(new_capsule(json.encode(r)))
+
+  return results
+`,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var capsules []config.Capsule
+	for _, data := range []string{
+		`{"records":[{"foo":1},{"foo":2}]}`,
+		`{"drop":true,"records":[{"foo":3}]}`,
+		`{"records":[{"foo":4}]}`,
+	} {
+		capsule := config.NewCapsule()
+		capsule.SetData([]byte(data))
+		capsules = append(capsules, capsule)
+	}
+
+	result, err := proc.Batch(ctx, capsules...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{`{"foo":1}`, `{"foo":2}`, `{"foo":4}`}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d, got %d", len(expected), len(result))
+	}
+
+	for i, res := range result {
+		if string(res.Data()) != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], res.Data())
+		}
+	}
+}
+
+func TestScriptLimits(t *testing.T) {
+	ctx := context.TODO()
+
+	var tests = []struct {
+		name    string
+		options map[string]interface{}
+		err     string
+	}{
+		{
+			"max_steps",
+			map[string]interface{}{
+				"max_steps": 1000,
+			},
+			"too many steps",
+		},
+		{
+			"timeout",
+			map[string]interface{}{
+				"max_steps": 1000000000,
+				"timeout":   10,
+			},
+			"deadline exceeded",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.options["script"] = `
+def process(capsule):
+  for i in range(100000000):
+    pass
+`
+			proc, err := newProcScript(ctx, config.Config{
+				Type: "script",
+				Settings: map[string]interface{}{
+					"options": test.options,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			capsule := config.NewCapsule()
+			_, err = proc.Apply(ctx, capsule)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected %s, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func benchmarkScript(b *testing.B, applier procScript, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkScript(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range scriptTests {
+		proc, err := newProcScript(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkScript(b, proc, capsule)
+			},
+		)
+	}
+}
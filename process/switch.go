@@ -0,0 +1,148 @@
+package process
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// switch processes data by applying the processors of the first case whose
+// condition matches the data. Cases are evaluated in order and evaluation
+// stops at the first match, so each condition is evaluated at most once per
+// capsule. If no case matches, then the default processors are applied.
+//
+// This processor supports the data and object handling patterns of the
+// processors in each case.
+type procSwitch struct {
+	process
+	Options procSwitchOptions `json:"options"`
+
+	cases    []procSwitchBranch
+	fallback []Applier
+}
+
+type procSwitchOptions struct {
+	// Cases are evaluated in order until a condition matches the data.
+	Cases []procSwitchCase `json:"cases"`
+	// Default processors are applied in series to the data if no case
+	// matches.
+	//
+	// This is optional and defaults to not modifying the data.
+	Default []config.Config `json:"default"`
+}
+
+type procSwitchCase struct {
+	// Condition that must match the data to apply the processors.
+	Condition condition.Config `json:"condition"`
+	// Processors applied in series to the data.
+	Processors []config.Config `json:"processors"`
+}
+
+// procSwitchBranch is a case that has been built into an operator and
+// appliers.
+type procSwitchBranch struct {
+	operator condition.Operator
+	appliers []Applier
+}
+
+// Create a new switch processor.
+func newProcSwitch(ctx context.Context, cfg config.Config) (p procSwitch, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procSwitch{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procSwitch{}, err
+	}
+
+	// error early if required options are missing
+	if len(p.Options.Cases) == 0 {
+		return procSwitch{}, fmt.Errorf("process: switch: cases: %v", errors.ErrMissingRequiredOption)
+	}
+
+	for _, c := range p.Options.Cases {
+		op, err := condition.NewOperator(ctx, c.Condition)
+		if err != nil {
+			return procSwitch{}, fmt.Errorf("process: switch: condition %+v: %v", c.Condition, err)
+		}
+
+		apps, err := NewAppliers(ctx, c.Processors...)
+		if err != nil {
+			return procSwitch{}, fmt.Errorf("process: switch: processors %+v: %v", c.Processors, err)
+		}
+
+		p.cases = append(p.cases, procSwitchBranch{
+			operator: op,
+			appliers: apps,
+		})
+	}
+
+	p.fallback, err = NewAppliers(ctx, p.Options.Default...)
+	if err != nil {
+		return procSwitch{}, fmt.Errorf("process: switch: default %+v: %v", p.Options.Default, err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procSwitch) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procSwitch) Close(ctx context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	for _, c := range p.cases {
+		if err := CloseAppliers(ctx, c.appliers...); err != nil {
+			return fmt.Errorf("close: switch: %v", err)
+		}
+	}
+
+	if err := CloseAppliers(ctx, p.fallback...); err != nil {
+		return fmt.Errorf("close: switch: %v", err)
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procSwitch) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procSwitch) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	for _, c := range p.cases {
+		ok, err := c.operator.Operate(ctx, capsule)
+		if err != nil {
+			return capsule, fmt.Errorf("process: switch: %v", err)
+		}
+
+		if !ok {
+			continue
+		}
+
+		capsule, err = Apply(ctx, capsule, c.appliers...)
+		if err != nil {
+			return capsule, fmt.Errorf("process: switch: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	capsule, err := Apply(ctx, capsule, p.fallback...)
+	if err != nil {
+		return capsule, fmt.Errorf("process: switch: %v", err)
+	}
+
+	return capsule, nil
+}
@@ -0,0 +1,158 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procSwitch{}
+	_ Batcher = procSwitch{}
+)
+
+func switchCase(typ, expression, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"condition": map[string]interface{}{
+			"operator": "all",
+			"inspectors": []config.Config{
+				{
+					Type: "strings",
+					Settings: map[string]interface{}{
+						"key": "a",
+						"options": map[string]interface{}{
+							"type":       typ,
+							"expression": expression,
+						},
+					},
+				},
+			},
+		},
+		"processors": []config.Config{
+			{
+				Type: "insert",
+				Settings: map[string]interface{}{
+					"set_key": "b",
+					"options": map[string]interface{}{
+						"value": value,
+					},
+				},
+			},
+		},
+	}
+}
+
+var switchCfg = config.Config{
+	Type: "switch",
+	Settings: map[string]interface{}{
+		"options": map[string]interface{}{
+			"cases": []map[string]interface{}{
+				switchCase("equals", "foo", "first"),
+				switchCase("starts_with", "f", "second"),
+			},
+			"default": []config.Config{
+				{
+					Type: "insert",
+					Settings: map[string]interface{}{
+						"set_key": "b",
+						"options": map[string]interface{}{
+							"value": "default",
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+var switchTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+}{
+	{
+		"first case",
+		switchCfg,
+		[]byte(`{"a":"foo"}`),
+		[]byte(`{"a":"foo","b":"first"}`),
+	},
+	{
+		"second case",
+		switchCfg,
+		[]byte(`{"a":"fizz"}`),
+		[]byte(`{"a":"fizz","b":"second"}`),
+	},
+	{
+		"default",
+		switchCfg,
+		[]byte(`{"a":"bar"}`),
+		[]byte(`{"a":"bar","b":"default"}`),
+	},
+	{
+		"no default",
+		config.Config{
+			Type: "switch",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"cases": []map[string]interface{}{
+						switchCase("equals", "foo", "first"),
+					},
+				},
+			},
+		},
+		[]byte(`{"a":"bar"}`),
+		[]byte(`{"a":"bar"}`),
+	},
+}
+
+func TestSwitch(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range switchTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule := config.NewCapsule()
+			capsule.SetData(test.test)
+
+			proc, err := newProcSwitch(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkSwitch(b *testing.B, applier procSwitch, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkSwitch(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range switchTests {
+		proc, err := newProcSwitch(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkSwitch(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,279 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/json"
+)
+
+// template processes data by constructing a string from a Go text template
+// (https://pkg.go.dev/text/template). If the data is a JSON object, then the
+// template can reference its values (e.g., {{.user}}), otherwise the data is
+// referenced as a string (e.g., {{.}}). Values in data and metadata can also
+// be retrieved with the get function (e.g., {{get "!metadata foo"}}), which
+// supports the same keys as other processors.
+//
+// Templates only have access to a limited set of functions:
+//
+// - get: retrieves a value from data or metadata
+//
+// - upper, lower, trim: changes a string
+//
+// - default: returns a default value if a value is empty (e.g., {{.foo | default "bar"}})
+//
+// - join: joins an array of values with a separator (e.g., {{.foo | join ","}})
+//
+// - json: encodes a value as JSON (e.g., {"user":{{json .user}}})
+//
+// - now: returns the current time
+//
+// - format_time: formats a time, RFC3339 string, or Unix epoch using a
+// pattern-based layout (e.g., {{format_time "2006-01-02" now}})
+//
+// - parse_time: parses a string using a pattern-based layout
+//
+// If set_key is not provided, then the processed data is the output of the
+// template.
+//
+// This processor supports the data and object handling patterns.
+type procTemplate struct {
+	process
+	Options procTemplateOptions `json:"options"`
+
+	// templates are pooled so that they are only cloned when they are
+	// executed concurrently.
+	templates *sync.Pool
+}
+
+// templateInstance is a template with a get function that retrieves values
+// from the capsule that the template is executed with.
+type templateInstance struct {
+	template *template.Template
+	capsule  *config.Capsule
+}
+
+type procTemplateOptions struct {
+	// Template is the Go text template that is executed for each object.
+	Template string `json:"template"`
+}
+
+// Create a new template processor.
+func newProcTemplate(ctx context.Context, cfg config.Config) (p procTemplate, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procTemplate{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procTemplate{}, err
+	}
+
+	// error early if required options are missing
+	if p.Options.Template == "" {
+		return procTemplate{}, fmt.Errorf("process: template: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	// the get function is replaced in each instance of the template
+	funcs := templateFuncs()
+	funcs["get"] = func(string) interface{} { return nil }
+
+	tmpl, err := template.New("template").Funcs(funcs).Parse(p.Options.Template)
+	if err != nil {
+		return procTemplate{}, fmt.Errorf("process: template: %v", err)
+	}
+
+	p.templates = &sync.Pool{
+		New: func() interface{} {
+			inst := &templateInstance{}
+
+			// cloning only fails if the template was executed, which never
+			// happens to the parsed template
+			inst.template = template.Must(tmpl.Clone())
+			inst.template.Funcs(template.FuncMap{
+				"get": func(key string) interface{} {
+					return templateValue(inst.capsule.Get(key))
+				},
+			})
+
+			return inst
+		},
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procTemplate) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procTemplate) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procTemplate) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procTemplate) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	var data interface{} = string(capsule.Data())
+	if json.Valid(capsule.Data()) {
+		dec := gojson.NewDecoder(bytes.NewReader(capsule.Data()))
+		dec.UseNumber()
+
+		if err := dec.Decode(&data); err != nil {
+			return capsule, fmt.Errorf("process: template: %v", err)
+		}
+	}
+
+	inst := p.templates.Get().(*templateInstance)
+	inst.capsule = &capsule
+
+	var buf bytes.Buffer
+	err := inst.template.Execute(&buf, data)
+
+	inst.capsule = nil
+	p.templates.Put(inst)
+
+	if err != nil {
+		return capsule, fmt.Errorf("process: template: %v", err)
+	}
+
+	// json processing
+	if p.SetKey != "" {
+		if err := capsule.Set(p.SetKey, buf.String()); err != nil {
+			return capsule, fmt.Errorf("process: template: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	capsule.SetData(buf.Bytes())
+	return capsule, nil
+}
+
+// templateValue converts a result to a value that is used in a template.
+// Numbers are not converted to floats so that they are printed as they
+// appear in the data.
+func templateValue(res json.Result) interface{} {
+	if !res.Exists() {
+		return nil
+	}
+
+	if res.Type.String() == "Number" {
+		return gojson.Number(res.Raw)
+	}
+
+	if res.IsArray() {
+		var values []interface{}
+		for _, r := range res.Array() {
+			values = append(values, templateValue(r))
+		}
+
+		return values
+	}
+
+	return res.Value()
+}
+
+// templateFuncs returns the functions that are available to templates.
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"default": func(def, value interface{}) interface{} {
+			if templateEmpty(value) {
+				return def
+			}
+
+			return value
+		},
+		"join": func(sep string, values []interface{}) string {
+			s := make([]string, len(values))
+			for i, v := range values {
+				s[i] = fmt.Sprint(v)
+			}
+
+			return strings.Join(s, sep)
+		},
+		"json": func(value interface{}) (string, error) {
+			b, err := gojson.Marshal(value)
+			return string(b), err
+		},
+		"now": time.Now,
+		"format_time": func(layout string, value interface{}) (string, error) {
+			ts, err := templateTime(value)
+			if err != nil {
+				return "", err
+			}
+
+			return ts.Format(layout), nil
+		},
+		"parse_time": func(layout, value string) (time.Time, error) {
+			return time.Parse(layout, value)
+		},
+	}
+}
+
+// templateEmpty returns true if a value is nil, an empty string, or an empty
+// array or object.
+func templateEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// templateTime converts a value to a time. Numbers are Unix epochs (supports
+// fractions of a second) and strings are RFC3339 timestamps.
+func templateTime(value interface{}) (time.Time, error) {
+	var epoch float64
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case gojson.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		epoch = f
+	case float64:
+		epoch = v
+	case int:
+		epoch = float64(v)
+	case string:
+		return time.Parse(time.RFC3339, v)
+	default:
+		return time.Time{}, fmt.Errorf("format_time: invalid value %v", value)
+	}
+
+	secs := math.Floor(epoch)
+	nanos := math.Round((epoch - secs) * 1000000000)
+	return time.Unix(int64(secs), int64(nanos)).UTC(), nil
+}
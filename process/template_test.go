@@ -0,0 +1,219 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procTemplate{}
+	_ Batcher = procTemplate{}
+)
+
+var templateTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"json",
+		config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"set_key": "email",
+				"options": map[string]interface{}{
+					"template": "{{.user}}@{{.domain}}",
+				},
+			},
+		},
+		[]byte(`{"user":"foo","domain":"example.com"}`),
+		[]byte(`{"user":"foo","domain":"example.com","email":"foo@example.com"}`),
+		nil,
+	},
+	{
+		"data",
+		config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"template": "{{upper .}}",
+				},
+			},
+		},
+		[]byte(`foo`),
+		[]byte(`FOO`),
+		nil,
+	},
+	{
+		"functions",
+		config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"set_key": "title",
+				"options": map[string]interface{}{
+					"template": `{{.severity | default "low" | upper}}: {{get "alert.name"}} ({{.hosts | join ", "}}) at {{format_time "2006-01-02" .ts}}`,
+				},
+			},
+		},
+		[]byte(`{"alert":{"name":"Brute Force"},"hosts":["a","b"],"ts":1639877490}`),
+		[]byte(`{"alert":{"name":"Brute Force"},"hosts":["a","b"],"ts":1639877490,"title":"LOW: Brute Force (a, b) at 2021-12-19"}`),
+		nil,
+	},
+	{
+		"numbers",
+		config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"template": `{{.count}} {{get "count"}}`,
+				},
+			},
+		},
+		[]byte(`{"count":1000000}`),
+		[]byte(`1000000 1000000`),
+		nil,
+	},
+	{
+		"json",
+		config.Config{
+			Type: "template",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"template": `{"user":{{json .user}},"count":{{json .count}}}`,
+				},
+			},
+		},
+		[]byte(`{"user":"a \"quoted\" name","count":10}`),
+		[]byte(`{"user":"a \"quoted\" name","count":10}`),
+		nil,
+	},
+}
+
+func TestTemplate(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range templateTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcTemplate(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestTemplateMetadata(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcTemplate(ctx, config.Config{
+		Type: "template",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"template": `{{get "!metadata bucket"}}/{{.key}}`,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	capsule := config.NewCapsule()
+	capsule.SetData([]byte(`{"key":"foo.json"}`))
+	if _, err := capsule.SetMetadata(map[string]interface{}{"bucket": "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := proc.Apply(ctx, capsule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte(`bar/foo.json`)
+	if !bytes.Equal(result.Data(), expected) {
+		t.Errorf("expected %s, got %s", expected, result.Data())
+	}
+}
+
+func TestTemplateConcurrent(t *testing.T) {
+	ctx := context.TODO()
+
+	proc, err := newProcTemplate(ctx, config.Config{
+		Type: "template",
+		Settings: map[string]interface{}{
+			"options": map[string]interface{}{
+				"template": `{{get "foo"}}`,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			expected := []byte(fmt.Sprint(i))
+
+			capsule := config.NewCapsule()
+			capsule.SetData([]byte(fmt.Sprintf(`{"foo":%d}`, i)))
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if !bytes.Equal(result.Data(), expected) {
+				t.Errorf("expected %s, got %s", expected, result.Data())
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func benchmarkTemplate(b *testing.B, applier procTemplate, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkTemplate(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range templateTests {
+		proc, err := newProcTemplate(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkTemplate(b, proc, capsule)
+			},
+		)
+	}
+}
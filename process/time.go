@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"time"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/brexhq/substation/condition"
 	"github.com/brexhq/substation/config"
 	"github.com/brexhq/substation/internal/errors"
@@ -19,6 +22,12 @@ import (
 type procTime struct {
 	process
 	Options procTimeOptions `json:"options"`
+
+	// these are parsed from the options when the processor is created.
+	location    *time.Location
+	setLocation *time.Location
+	offset      time.Duration
+	truncate    time.Duration
 }
 
 type procTimeOptions struct {
@@ -28,13 +37,36 @@ type procTimeOptions struct {
 	//
 	// - pattern-based layouts (https://gobyexample.com/time-formatting-parsing)
 	//
+	// - strftime patterns (e.g., %Y-%m-%d %H:%M:%S): any format that contains
+	// "%" is interpreted as a strftime pattern. Supported directives are %a,
+	// %A, %b, %B, %d, %D, %e, %f (must follow a period), %F, %h, %H, %I, %m,
+	// %M, %p, %R, %S, %T, %y, %Y, %z, %Z, and %%.
+	//
+	// - rfc3339: RFC 3339 (supports fractions of a second)
+	//
 	// - unix: epoch (supports fractions of a second)
 	//
 	// - unix_milli: epoch milliseconds
 	//
+	// - unix_nano: epoch nanoseconds
+	//
+	// - auto: the format is detected from the data. Numbers are interpreted
+	// as epochs based on their magnitude (seconds, milliseconds,
+	// microseconds, or nanoseconds) and strings are parsed using common
+	// formats (e.g., RFC 3339, RFC 1123, Common Log Format).
+	//
 	// - now: current time
+	//
+	// This is optional if Formats is set.
 	Format string `json:"format"`
-	// Location is the timezone abbreviation of the data.
+	// Formats are fallback time formats of the data. If the data cannot be
+	// parsed using Format, then each format is tried in order until one
+	// succeeds. This is useful for data that contains mixed formats.
+	//
+	// This is optional and has no default.
+	Formats []string `json:"formats"`
+	// Location is the IANA timezone name (e.g., America/New_York) of the data.
+	// This is ignored if the format contains a timezone or is an epoch.
 	//
 	// This is optional and defaults to UTC.
 	Location string `json:"location"`
@@ -44,14 +76,97 @@ type procTimeOptions struct {
 	//
 	// - pattern-based layouts (https://gobyexample.com/time-formatting-parsing)
 	//
+	// - strftime patterns (e.g., %Y-%m-%d %H:%M:%S)
+	//
+	// - rfc3339: RFC 3339 (includes fractions of a second if they are not zero)
+	//
 	// - unix: epoch (supports fractions of a second)
 	//
 	// - unix_milli: epoch milliseconds
+	//
+	// - unix_nano: epoch nanoseconds
+	//
+	// If DiffKey is set, then this must be one of:
+	//
+	// - unix: difference in seconds
+	//
+	// - unix_milli: difference in milliseconds
+	//
+	// - unix_nano: difference in nanoseconds
+	//
+	// - duration: difference as a duration string (e.g., 1h2m3s)
 	SetFormat string `json:"set_format"`
-	// SetLocation is the timezone abbreviation of the processed data.
+	// SetLocation is the IANA timezone name (e.g., America/New_York) of the
+	// processed data.
 	//
-	// This is optional and defaults to UTC.
+	// This is optional and defaults to UTC. If Format is "now", then this
+	// defaults to the local time zone.
 	SetLocation string `json:"set_location"`
+	// Offset is a duration (e.g., 1h, -30m) that is added to the time. Negative
+	// durations subtract from the time.
+	//
+	// This is optional and has no default.
+	Offset string `json:"offset"`
+	// Truncate is an interval (e.g., 1m, 1h, 24h) that the time is rounded
+	// down to. Intervals are relative to the zero time in UTC, so 24h
+	// truncates the time to midnight UTC. If Offset is set, then it is
+	// applied before the time is truncated.
+	//
+	// This is optional and has no default.
+	Truncate string `json:"truncate"`
+	// DiffKey retrieves a time from an object that is subtracted from the time
+	// in Key. The difference is written to SetKey using SetFormat. Both
+	// times are parsed using the same formats and location.
+	//
+	// This is optional and only supports the object handling pattern.
+	DiffKey string `json:"diff_key"`
+}
+
+// timeAutoLayouts are the layouts that are tried, in order, when the time
+// format is auto.
+var timeAutoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+	// Common Log Format
+	"02/Jan/2006:15:04:05 -0700",
+}
+
+// timeStrftime maps strftime directives to pattern-based layouts.
+var timeStrftime = map[byte]string{
+	'a': "Mon",
+	'A': "Monday",
+	'b': "Jan",
+	'B': "January",
+	'd': "02",
+	'D': "01/02/06",
+	'e': "_2",
+	'f': "000000",
+	'F': "2006-01-02",
+	'h': "Jan",
+	'H': "15",
+	'I': "03",
+	'm': "01",
+	'M': "04",
+	'p': "PM",
+	'R': "15:04",
+	'S': "05",
+	'T': "15:04:05",
+	'y': "06",
+	'Y': "2006",
+	'z': "-0700",
+	'Z': "MST",
+	'%': "%",
 }
 
 // String returns the processor settings as an object.
@@ -76,10 +191,64 @@ func newProcTime(ctx context.Context, cfg config.Config) (p procTime, err error)
 	}
 
 	// error early if required options are missing
-	if p.Options.Format == "" || p.Options.SetFormat == "" {
+	if (p.Options.Format == "" && len(p.Options.Formats) == 0) || p.Options.SetFormat == "" {
 		return procTime{}, fmt.Errorf("process: time: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
 	}
 
+	for _, f := range append([]string{p.Options.Format}, p.Options.Formats...) {
+		if _, err := timeLayout(f); err != nil {
+			return procTime{}, fmt.Errorf("process: time: format %s: %v", f, err)
+		}
+	}
+
+	if p.Options.DiffKey != "" {
+		if p.Key == "" || p.SetKey == "" {
+			return procTime{}, fmt.Errorf("process: time: diff_key %s key %s set_key %s: %v", p.Options.DiffKey, p.Key, p.SetKey, errInvalidDataPattern)
+		}
+
+		//  validate option.set_format
+		if !slices.Contains(
+			[]string{
+				"unix",
+				"unix_milli",
+				"unix_nano",
+				"duration",
+			},
+			p.Options.SetFormat) {
+			return procTime{}, fmt.Errorf("process: time: set_format %q: %v", p.Options.SetFormat, errors.ErrInvalidOption)
+		}
+	} else if _, err := timeLayout(p.Options.SetFormat); err != nil || p.Options.SetFormat == "auto" || p.Options.SetFormat == "now" {
+		return procTime{}, fmt.Errorf("process: time: set_format %q: %v", p.Options.SetFormat, errors.ErrInvalidOption)
+	}
+
+	p.location, err = time.LoadLocation(p.Options.Location)
+	if err != nil {
+		return procTime{}, fmt.Errorf("process: time: location %s: %v", p.Options.Location, err)
+	}
+
+	p.setLocation, err = time.LoadLocation(p.Options.SetLocation)
+	if err != nil {
+		return procTime{}, fmt.Errorf("process: time: set_location %s: %v", p.Options.SetLocation, err)
+	}
+
+	if p.Options.Offset != "" {
+		p.offset, err = time.ParseDuration(p.Options.Offset)
+		if err != nil {
+			return procTime{}, fmt.Errorf("process: time: offset %s: %v", p.Options.Offset, err)
+		}
+	}
+
+	if p.Options.Truncate != "" {
+		p.truncate, err = time.ParseDuration(p.Options.Truncate)
+		if err != nil {
+			return procTime{}, fmt.Errorf("process: time: truncate %s: %v", p.Options.Truncate, err)
+		}
+
+		if p.truncate <= 0 {
+			return procTime{}, fmt.Errorf("process: time: truncate %s: %v", p.Options.Truncate, errors.ErrInvalidOption)
+		}
+	}
+
 	return p, nil
 }
 
@@ -93,16 +262,16 @@ func (p procTime) Batch(ctx context.Context, capsules ...config.Capsule) ([]conf
 func (p procTime) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
 	// "now" processing, supports json and data
 	if p.Options.Format == "now" {
-		ts := time.Now()
+		// the current time is in the local time zone unless a location is
+		// configured
+		ts := p.adjust(time.Now())
+		if p.Options.SetLocation != "" {
+			ts = ts.In(p.setLocation)
+		}
 
-		var value interface{}
-		switch p.Options.SetFormat {
-		case "unix":
-			value = ts.Unix()
-		case "unix_milli":
-			value = ts.UnixMilli()
-		default:
-			value = ts.Format(p.Options.SetFormat)
+		value, err := p.formatTime(ts)
+		if err != nil {
+			return capsule, fmt.Errorf("process: time: %v", err)
 		}
 
 		if p.SetKey != "" {
@@ -132,9 +301,26 @@ func (p procTime) Apply(ctx context.Context, capsule config.Capsule) (config.Cap
 			return capsule, nil
 		}
 
-		value, err := p.procTime(result)
-		if err != nil {
-			return capsule, fmt.Errorf("process: time: %v", err)
+		var value interface{}
+		if p.Options.DiffKey != "" {
+			diff := capsule.Get(p.Options.DiffKey)
+			if diff.Type.String() == "Null" {
+				return capsule, nil
+			}
+
+			v, err := p.procDiff(result, diff)
+			if err != nil {
+				return capsule, fmt.Errorf("process: time: %v", err)
+			}
+
+			value = v
+		} else {
+			v, err := p.procTime(result)
+			if err != nil {
+				return capsule, fmt.Errorf("process: time: %v", err)
+			}
+
+			value = v
 		}
 
 		if err := capsule.Set(p.SetKey, value); err != nil {
@@ -171,51 +357,210 @@ func (p procTime) Apply(ctx context.Context, capsule config.Capsule) (config.Cap
 }
 
 func (p procTime) procTime(result json.Result) (interface{}, error) {
-	var timeDate time.Time
-	switch p.Options.Format {
+	ts, err := p.parse(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.format(p.adjust(ts))
+}
+
+// procDiff returns the difference between two times.
+func (p procTime) procDiff(result, diff json.Result) (interface{}, error) {
+	var ts [2]time.Time
+	for i, r := range []json.Result{result, diff} {
+		t, err := p.parse(r)
+		if err != nil {
+			return nil, err
+		}
+
+		ts[i] = p.adjust(t)
+	}
+
+	d := ts[0].Sub(ts[1])
+	switch p.Options.SetFormat {
 	case "unix":
-		secs := math.Floor(result.Float())
-		nanos := math.Round((result.Float() - secs) * 1000000000)
-		timeDate = time.Unix(int64(secs), int64(nanos))
+		return int64(d / time.Second), nil
 	case "unix_milli":
-		secs := math.Floor(result.Float())
-		timeDate = time.Unix(0, int64(secs)*1000000)
+		return d.Milliseconds(), nil
+	case "unix_nano":
+		return d.Nanoseconds(), nil
 	default:
-		if p.Options.Location != "" {
-			loc, err := time.LoadLocation(p.Options.Location)
-			if err != nil {
-				return nil, fmt.Errorf("process: time: location %s: %v", p.Options.Location, err)
-			}
+		return d.String(), nil
+	}
+}
 
-			timeDate, err = time.ParseInLocation(p.Options.Format, result.String(), loc)
-			if err != nil {
-				return nil, fmt.Errorf("process: time parse: format %s location %s: %v", p.Options.Format, p.Options.Location, err)
-			}
-		} else {
-			var err error
-			timeDate, err = time.Parse(p.Options.Format, result.String())
-			if err != nil {
-				return nil, fmt.Errorf("process: time parse: format %s: %v", p.Options.Format, err)
-			}
+// parse parses the time using each format until one succeeds.
+func (p procTime) parse(result json.Result) (time.Time, error) {
+	formats := p.Options.Formats
+	if p.Options.Format != "" {
+		formats = append([]string{p.Options.Format}, formats...)
+	}
+
+	var err error
+	for _, f := range formats {
+		var ts time.Time
+		ts, err = timeParse(f, result, p.location)
+		if err == nil {
+			return ts, nil
 		}
 	}
 
-	timeDate = timeDate.UTC()
+	return time.Time{}, fmt.Errorf("process: time parse: formats %v location %s: %v", formats, p.Options.Location, err)
+}
+
+// adjust applies the offset and truncation to the time.
+func (p procTime) adjust(ts time.Time) time.Time {
+	if p.offset != 0 {
+		ts = ts.Add(p.offset)
+	}
+
+	if p.truncate != 0 {
+		ts = ts.Truncate(p.truncate)
+	}
+
+	return ts
+}
+
+// format converts the time to the location and format of the processed data.
+func (p procTime) format(ts time.Time) (interface{}, error) {
+	ts = ts.UTC()
 	if p.Options.SetLocation != "" {
-		loc, err := time.LoadLocation(p.Options.SetLocation)
+		ts = ts.In(p.setLocation)
+	}
+
+	return p.formatTime(ts)
+}
+
+// formatTime converts the time to the format of the processed data without
+// changing its location.
+func (p procTime) formatTime(ts time.Time) (interface{}, error) {
+	switch p.Options.SetFormat {
+	case "unix":
+		return ts.Unix(), nil
+	case "unix_milli":
+		return ts.UnixMilli(), nil
+	case "unix_nano":
+		return ts.UnixNano(), nil
+	default:
+		layout, err := timeLayout(p.Options.SetFormat)
 		if err != nil {
-			return nil, fmt.Errorf("process: time: location %s: %v", p.Options.SetLocation, err)
+			return nil, fmt.Errorf("process: time: set_format %s: %v", p.Options.SetFormat, err)
 		}
 
-		timeDate = timeDate.In(loc)
+		return ts.Format(layout), nil
 	}
+}
 
-	switch p.Options.SetFormat {
+// timeParse parses the time using a single format. Epochs are only parsed
+// from numbers so that fallback formats are tried for other values.
+func timeParse(format string, result json.Result, loc *time.Location) (time.Time, error) {
+	switch format {
 	case "unix":
-		return timeDate.Unix(), nil
+		if !timeIsNumber(result) {
+			return time.Time{}, fmt.Errorf("format %s: %q is not a number", format, result.String())
+		}
+
+		secs := math.Floor(result.Float())
+		nanos := math.Round((result.Float() - secs) * 1000000000)
+		return time.Unix(int64(secs), int64(nanos)), nil
 	case "unix_milli":
-		return timeDate.UnixMilli(), nil
+		if !timeIsNumber(result) {
+			return time.Time{}, fmt.Errorf("format %s: %q is not a number", format, result.String())
+		}
+
+		secs := math.Floor(result.Float())
+		return time.Unix(0, int64(secs)*1000000), nil
+	case "unix_nano":
+		if !timeIsNumber(result) {
+			return time.Time{}, fmt.Errorf("format %s: %q is not a number", format, result.String())
+		}
+
+		return time.Unix(0, result.Int()), nil
+	case "auto":
+		return timeParseAuto(result, loc)
 	default:
-		return timeDate.Format(p.Options.SetFormat), nil
+		layout, err := timeLayout(format)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		ts, err := time.ParseInLocation(layout, result.String(), loc)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("format %s: %v", format, err)
+		}
+
+		return ts, nil
+	}
+}
+
+// timeParseAuto parses the time by detecting its format.
+func timeParseAuto(result json.Result, loc *time.Location) (time.Time, error) {
+	if timeIsNumber(result) {
+		// the magnitude of the number determines the precision of the epoch;
+		// seconds are supported until the year 5138
+		switch f := math.Abs(result.Float()); {
+		case f < 1e11:
+			return timeParse("unix", result, loc)
+		case f < 1e14:
+			return time.UnixMilli(int64(result.Float())), nil
+		case f < 1e17:
+			return time.UnixMicro(result.Int()), nil
+		default:
+			return time.Unix(0, result.Int()), nil
+		}
+	}
+
+	for _, layout := range timeAutoLayouts {
+		if ts, err := time.ParseInLocation(layout, result.String(), loc); err == nil {
+			return ts, nil
+		}
 	}
+
+	return time.Time{}, fmt.Errorf("format auto: %q: unknown format", result.String())
+}
+
+// timeIsNumber returns true if the result is a number or a string that
+// contains a number.
+func timeIsNumber(result json.Result) bool {
+	if result.Type.String() == "Number" {
+		return true
+	}
+
+	_, err := strconv.ParseFloat(result.String(), 64)
+	return err == nil
+}
+
+// timeLayout converts a time format to a pattern-based layout. Strftime
+// patterns are converted and other formats are returned unchanged.
+func timeLayout(format string) (string, error) {
+	if format == "rfc3339" {
+		return time.RFC3339Nano, nil
+	}
+
+	if !strings.Contains(format, "%") {
+		return format, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+
+		i++
+		if i == len(format) {
+			return "", fmt.Errorf("strftime %s: trailing %%: %v", format, errors.ErrInvalidOption)
+		}
+
+		layout, ok := timeStrftime[format[i]]
+		if !ok {
+			return "", fmt.Errorf("strftime %s: directive %%%c: %v", format, format[i], errors.ErrInvalidOption)
+		}
+
+		b.WriteString(layout)
+	}
+
+	return b.String(), nil
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"github.com/brexhq/substation/config"
 )
@@ -227,6 +228,244 @@ var timeTests = []struct {
 		[]byte(`{"time":"2020-Jan-29 Wednesday 03:19:25"}`),
 		nil,
 	},
+	{
+		"JSON fallback formats",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "unix",
+					"formats":    []string{"2006-01-02", "rfc3339"},
+					"set_format": setFmt,
+				},
+			},
+		},
+		[]byte(`{"time":"2021-03-06T00:02:57Z"}`),
+		[]byte(`{"time":"2021-03-06T00:02:57.000000Z"}`),
+		nil,
+	},
+	{
+		"JSON formats without format",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"formats":    []string{"unix_milli"},
+					"set_format": "rfc3339",
+				},
+			},
+		},
+		[]byte(`{"time":1654459632263}`),
+		[]byte(`{"time":"2022-06-05T20:07:12.263Z"}`),
+		nil,
+	},
+	{
+		"JSON strftime",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "%d/%b/%Y:%H:%M:%S %z",
+					"set_format": "%Y-%m-%d %H:%M:%S.%f",
+				},
+			},
+		},
+		[]byte(`{"time":"06/Mar/2021:00:02:57 -0500"}`),
+		[]byte(`{"time":"2021-03-06 05:02:57.000000"}`),
+		nil,
+	},
+	{
+		"JSON rfc3339 to unix_nano",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "rfc3339",
+					"set_format": "unix_nano",
+				},
+			},
+		},
+		[]byte(`{"time":"2021-12-19T01:31:30.061000123Z"}`),
+		[]byte(`{"time":1639877490061000123}`),
+		nil,
+	},
+	{
+		"JSON unix_nano",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "unix_nano",
+					"set_format": "rfc3339",
+				},
+			},
+		},
+		[]byte(`{"time":1639877490061000123}`),
+		[]byte(`{"time":"2021-12-19T01:31:30.061000123Z"}`),
+		nil,
+	},
+	{
+		"JSON IANA location",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":       "2006-01-02 15:04:05",
+					"location":     "Europe/Berlin",
+					"set_format":   "rfc3339",
+					"set_location": "Asia/Tokyo",
+				},
+			},
+		},
+		[]byte(`{"time":"2021-07-01 12:00:00"}`),
+		[]byte(`{"time":"2021-07-01T19:00:00+09:00"}`),
+		nil,
+	},
+	{
+		"JSON offset",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "rfc3339",
+					"set_format": "rfc3339",
+					"offset":     "-36h",
+				},
+			},
+		},
+		[]byte(`{"time":"2021-03-06T00:02:57Z"}`),
+		[]byte(`{"time":"2021-03-04T12:02:57Z"}`),
+		nil,
+	},
+	{
+		"JSON truncate",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "rfc3339",
+					"set_format": "rfc3339",
+					"offset":     "1h",
+					"truncate":   "24h",
+				},
+			},
+		},
+		[]byte(`{"time":"2021-03-06T23:02:57Z"}`),
+		[]byte(`{"time":"2021-03-07T00:00:00Z"}`),
+		nil,
+	},
+	{
+		"JSON diff",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "rfc3339",
+					"set_format": "unix",
+					"diff_key":   "start",
+				},
+			},
+		},
+		[]byte(`{"start":"2021-03-06T00:00:00Z","time":"2021-03-06T00:02:57Z"}`),
+		[]byte(`{"start":"2021-03-06T00:00:00Z","time":177}`),
+		nil,
+	},
+	{
+		"JSON diff duration",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": map[string]interface{}{
+					"format":     "auto",
+					"set_format": "duration",
+					"diff_key":   "start",
+				},
+			},
+		},
+		[]byte(`{"start":1614988800,"time":"2021-03-06T01:02:57.5Z"}`),
+		[]byte(`{"start":1614988800,"time":"1h2m57.5s"}`),
+		nil,
+	},
+	{
+		"data auto epoch milliseconds",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"format":     "auto",
+					"set_format": setFmt,
+				},
+			},
+		},
+		[]byte(`1639877490061`),
+		[]byte(`2021-12-19T01:31:30.061000Z`),
+		nil,
+	},
+	{
+		"data auto epoch nanoseconds",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"format":     "auto",
+					"set_format": setFmt,
+				},
+			},
+		},
+		[]byte(`1639877490061000000`),
+		[]byte(`2021-12-19T01:31:30.061000Z`),
+		nil,
+	},
+	{
+		"data auto RFC 1123",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"format":     "auto",
+					"set_format": setFmt,
+				},
+			},
+		},
+		[]byte(`Sat, 06 Mar 2021 00:02:57 GMT`),
+		[]byte(`2021-03-06T00:02:57.000000Z`),
+		nil,
+	},
+	{
+		"data auto Common Log Format",
+		config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"format":     "auto",
+					"set_format": setFmt,
+				},
+			},
+		},
+		[]byte(`06/Mar/2021:00:02:57 +0100`),
+		[]byte(`2021-03-05T23:02:57.000000Z`),
+		nil,
+	},
 }
 
 func TestTime(t *testing.T) {
@@ -254,6 +493,74 @@ func TestTime(t *testing.T) {
 	}
 }
 
+func TestTimeNowLocation(t *testing.T) {
+	ctx := context.TODO()
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		location string
+		expected *time.Location
+	}{
+		// the current time is in the local time zone by default
+		{"", time.Local},
+		{"America/New_York", ny},
+	} {
+		proc, err := newProcTime(ctx, config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"format":       "now",
+					"set_format":   "-07:00",
+					"set_location": test.location,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := proc.Apply(ctx, config.NewCapsule())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := time.Now().In(test.expected).Format("-07:00")
+		if string(result.Data()) != expected {
+			t.Errorf("expected %s, got %s", expected, result.Data())
+		}
+	}
+}
+
+func TestTimeInvalid(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, options := range []map[string]interface{}{
+		{"set_format": setFmt},
+		{"format": "%Y-%Q", "set_format": setFmt},
+		{"format": "unix", "set_format": "auto"},
+		{"format": "unix", "set_format": setFmt, "location": "Mars/Olympus_Mons"},
+		{"format": "unix", "set_format": setFmt, "offset": "1 day"},
+		{"format": "unix", "set_format": setFmt, "truncate": "-1h"},
+		{"format": "unix", "set_format": setFmt, "diff_key": "start"},
+	} {
+		_, err := newProcTime(ctx, config.Config{
+			Type: "time",
+			Settings: map[string]interface{}{
+				"key":     "time",
+				"set_key": "time",
+				"options": options,
+			},
+		})
+		if err == nil {
+			t.Errorf("expected error for options %v", options)
+		}
+	}
+}
+
 func benchmarkTime(b *testing.B, applier procTime, test config.Capsule) {
 	ctx := context.TODO()
 	for i := 0; i < b.N; i++ {
@@ -0,0 +1,331 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/kv"
+	"github.com/brexhq/substation/internal/secrets"
+)
+
+// errTokenizeNotFound is returned when the tokenize processor cannot find
+// the original value of a token in the vault.
+var errTokenizeNotFound = fmt.Errorf("token not found")
+
+// tokenize processes data by replacing values with keyed tokens (pseudonyms).
+// Tokens are created using a hash-based message authentication code (HMAC), so
+// unlike the hash processor the tokens cannot be reversed with a dictionary
+// attack unless the secret is known. Identical values tokenized with the same
+// secret always produce identical tokens, which preserves the ability to join
+// and count values across data.
+//
+// If a vault is configured, then tokens and their original values are put
+// into a KV store and tokens can be reversed by the processor.
+//
+// This processor supports the data and object handling patterns. Multiple
+// values can be tokenized in a single object by configuring fields.
+type procTokenize struct {
+	process
+	Options procTokenizeOptions `json:"options"`
+
+	vault kv.Storer
+}
+
+type procTokenizeOptions struct {
+	// Direction determines whether values are tokenized or detokenized.
+	//
+	// Must be one of:
+	//
+	// - to: replace values with tokens
+	//
+	// - from: replace tokens with values (requires a vault)
+	//
+	// This is optional and defaults to to.
+	Direction string `json:"direction"`
+	// Algorithm is the HMAC algorithm used to create tokens.
+	//
+	// Must be one of:
+	//
+	// - hmac_sha256
+	//
+	// - hmac_sha512
+	//
+	// This is optional and defaults to hmac_sha256.
+	Algorithm string `json:"algorithm"`
+	// Secret is the key used to create tokens. This should be
+	// retrieved from secrets (e.g., ${SECRETS_ENV:TOKENIZE_SECRET}).
+	//
+	// This is required when tokenizing values.
+	Secret string `json:"secret"`
+	// Fields are pairs of keys that are tokenized in a single object. If
+	// fields are configured, then the processor's key and set_key are not
+	// used.
+	//
+	// This is optional and defaults to an empty list.
+	Fields []procTokenizeField `json:"fields"`
+	// Prefix is prepended to every token (e.g., tok_).
+	//
+	// This is optional and defaults to an empty string.
+	Prefix string `json:"prefix"`
+	// Length truncates tokens to a number of hex characters (not including
+	// the prefix). Shorter tokens increase the likelihood of collisions.
+	//
+	// This is optional and defaults to the full length of the HMAC (64
+	// characters for hmac_sha256, 128 characters for hmac_sha512).
+	Length int `json:"length"`
+	// Vault determines the type of KV store used to store tokens and their
+	// original values. Refer to internal/kv for more information.
+	//
+	// This is optional and defaults to not storing tokens.
+	Vault config.Config `json:"vault"`
+	// OffsetTTL is an offset (in seconds) used to determine the time-to-live
+	// (TTL) of tokens stored in the vault.
+	//
+	// This is optional and defaults to using no TTL.
+	OffsetTTL int `json:"offset_ttl"`
+}
+
+type procTokenizeField struct {
+	Key    string `json:"key"`
+	SetKey string `json:"set_key"`
+}
+
+// Create a new tokenize processor.
+func newProcTokenize(ctx context.Context, cfg config.Config) (p procTokenize, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procTokenize{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procTokenize{}, err
+	}
+
+	if p.Options.Direction == "" {
+		p.Options.Direction = "to"
+	}
+
+	//  validate option.direction
+	if !slices.Contains(
+		[]string{
+			"to",
+			"from",
+		},
+		p.Options.Direction) {
+		return procTokenize{}, fmt.Errorf("process: tokenize: direction %q: %v", p.Options.Direction, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Algorithm == "" {
+		p.Options.Algorithm = "hmac_sha256"
+	}
+
+	//  validate option.algorithm
+	if !slices.Contains(
+		[]string{
+			"hmac_sha256",
+			"hmac_sha512",
+		},
+		p.Options.Algorithm) {
+		return procTokenize{}, fmt.Errorf("process: tokenize: algorithm %q: %v", p.Options.Algorithm, errors.ErrInvalidOption)
+	}
+
+	max := sha256.Size * 2
+	if p.Options.Algorithm == "hmac_sha512" {
+		max = sha512.Size * 2
+	}
+
+	if p.Options.Length < 0 || p.Options.Length > max {
+		return procTokenize{}, fmt.Errorf("process: tokenize: length %d: %v", p.Options.Length, errors.ErrInvalidOption)
+	}
+
+	if p.Options.Direction == "to" && p.Options.Secret == "" {
+		return procTokenize{}, fmt.Errorf("process: tokenize: secret: %v", errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.Direction == "from" && p.Options.Vault.Type == "" {
+		return procTokenize{}, fmt.Errorf("process: tokenize: vault: %v", errors.ErrMissingRequiredOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procTokenize{}, fmt.Errorf("process: tokenize: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	for _, f := range p.Options.Fields {
+		if f.Key == "" || f.SetKey == "" {
+			return procTokenize{}, fmt.Errorf("process: tokenize: field key %s set_key %s: %v", f.Key, f.SetKey, errInvalidDataPattern)
+		}
+	}
+
+	if p.Options.Vault.Type == "" {
+		return p, nil
+	}
+
+	p.vault, err = kv.Get(p.Options.Vault)
+	if err != nil {
+		return procTokenize{}, fmt.Errorf("process: tokenize: %v", err)
+	}
+
+	// lazy load the KV store
+	if !p.vault.IsEnabled() {
+		if err := p.vault.Setup(ctx); err != nil {
+			return procTokenize{}, fmt.Errorf("process: tokenize: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procTokenize) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procTokenize) Close(context.Context) error {
+	if p.IgnoreClose || p.vault == nil {
+		return nil
+	}
+
+	if p.vault.IsEnabled() {
+		if err := p.vault.Close(); err != nil {
+			return fmt.Errorf("close: tokenize: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procTokenize) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procTokenize) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	fields := p.Options.Fields
+	if len(fields) == 0 && p.Key != "" && p.SetKey != "" {
+		fields = []procTokenizeField{{Key: p.Key, SetKey: p.SetKey}}
+	}
+
+	if len(fields) > 0 {
+		for _, f := range fields {
+			result := capsule.Get(f.Key)
+			if !result.Exists() {
+				continue
+			}
+
+			value, err := p.tokenize(ctx, result.String())
+			if err != nil {
+				return capsule, fmt.Errorf("process: tokenize: %v", err)
+			}
+
+			if err := capsule.Set(f.SetKey, value); err != nil {
+				return capsule, fmt.Errorf("process: tokenize: %v", err)
+			}
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, err := p.tokenize(ctx, string(capsule.Data()))
+		if err != nil {
+			return capsule, fmt.Errorf("process: tokenize: %v", err)
+		}
+
+		capsule.SetData([]byte(value))
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: tokenize: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+// tokenize returns either a token or the original value of a token,
+// depending on the direction of the processor.
+func (p procTokenize) tokenize(ctx context.Context, s string) (string, error) {
+	switch p.Options.Direction {
+	case "to":
+		token, err := p.token(ctx, s)
+		if err != nil {
+			return "", err
+		}
+
+		if p.vault == nil {
+			return token, nil
+		}
+
+		if p.Options.OffsetTTL == 0 {
+			if err := p.vault.Set(ctx, token, s); err != nil {
+				return "", err
+			}
+		} else {
+			ttl := time.Now().Add(time.Duration(p.Options.OffsetTTL) * time.Second).Unix()
+			if err := p.vault.SetWithTTL(ctx, token, s, ttl); err != nil {
+				return "", err
+			}
+		}
+
+		return token, nil
+	case "from":
+		v, err := p.vault.Get(ctx, s)
+		if err != nil {
+			return "", err
+		}
+
+		if v == nil {
+			return "", fmt.Errorf("token %s: %v", s, errTokenizeNotFound)
+		}
+
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("direction %s: %v", p.Options.Direction, errInvalidDirection)
+	}
+}
+
+// token returns the HMAC of a value, formatted using the prefix and length
+// options. Secrets are interpolated every time a token is created so that
+// they can be rotated without restarting the application.
+func (p procTokenize) token(ctx context.Context, s string) (string, error) {
+	secret, err := secrets.Interpolate(ctx, p.Options.Secret)
+	if err != nil {
+		return "", err
+	}
+
+	var fn func() hash.Hash
+	switch p.Options.Algorithm {
+	case "hmac_sha256":
+		fn = sha256.New
+	case "hmac_sha512":
+		fn = sha512.New
+	default:
+		return "", fmt.Errorf("algorithm %s: %v", p.Options.Algorithm, errors.ErrInvalidOption)
+	}
+
+	mac := hmac.New(fn, []byte(secret))
+	mac.Write([]byte(s))
+	token := hex.EncodeToString(mac.Sum(nil))
+
+	if p.Options.Length > 0 {
+		token = token[:p.Options.Length]
+	}
+
+	return p.Options.Prefix + token, nil
+}
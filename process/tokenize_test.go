@@ -0,0 +1,222 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procTokenize{}
+	_ Batcher = procTokenize{}
+)
+
+var tokenizeTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON",
+		config.Config{
+			Type: "tokenize",
+			Settings: map[string]interface{}{
+				"key":     "foo",
+				"set_key": "foo",
+				"options": map[string]interface{}{
+					"secret": "secret",
+				},
+			},
+		},
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`{"foo":"68c70ddb0cf1e172e9f70dc23ebc0d6fa3ed4f3102ce430f22211f0ea5439389"}`),
+		nil,
+	},
+	{
+		"JSON secret",
+		config.Config{
+			Type: "tokenize",
+			Settings: map[string]interface{}{
+				"key":     "foo",
+				"set_key": "foo",
+				"options": map[string]interface{}{
+					"secret": "${SECRETS_ENV:SUBSTATION_TOKENIZE_TEST_SECRET}",
+				},
+			},
+		},
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`{"foo":"68c70ddb0cf1e172e9f70dc23ebc0d6fa3ed4f3102ce430f22211f0ea5439389"}`),
+		nil,
+	},
+	{
+		"JSON fields",
+		config.Config{
+			Type: "tokenize",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"algorithm": "hmac_sha512",
+					"secret":    "secret",
+					"prefix":    "tok_",
+					"length":    16,
+					"fields": []interface{}{
+						map[string]interface{}{
+							"key":     "foo",
+							"set_key": "foo",
+						},
+						map[string]interface{}{
+							"key":     "baz",
+							"set_key": "qux",
+						},
+						map[string]interface{}{
+							"key":     "quux",
+							"set_key": "quux",
+						},
+					},
+				},
+			},
+		},
+		[]byte(`{"foo":"bar","baz":"baz"}`),
+		[]byte(`{"foo":"tok_2107777fafe6d226","baz":"baz","qux":"tok_e704f5532f5d5b80"}`),
+		nil,
+	},
+	{
+		"data",
+		config.Config{
+			Type: "tokenize",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"secret": "secret",
+					"length": 8,
+				},
+			},
+		},
+		[]byte(`bar`),
+		[]byte(`68c70ddb`),
+		nil,
+	},
+}
+
+func TestTokenize(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	t.Setenv("SUBSTATION_TOKENIZE_TEST_SECRET", "secret")
+
+	for _, test := range tokenizeTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcTokenize(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Apply(ctx, capsule)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func TestTokenizeVault(t *testing.T) {
+	ctx := context.TODO()
+
+	vault := map[string]interface{}{
+		"type": "memory",
+		"settings": map[string]interface{}{
+			"capacity": 10,
+		},
+	}
+
+	to, err := newProcTokenize(ctx, config.Config{
+		Type: "tokenize",
+		Settings: map[string]interface{}{
+			"key":     "foo",
+			"set_key": "foo",
+			"options": map[string]interface{}{
+				"secret": "secret",
+				"prefix": "tok_",
+				"vault":  vault,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := newProcTokenize(ctx, config.Config{
+		Type: "tokenize",
+		Settings: map[string]interface{}{
+			"key":     "foo",
+			"set_key": "foo",
+			"options": map[string]interface{}{
+				"direction": "from",
+				"vault":     vault,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test := []byte(`{"foo":"bar"}`)
+
+	token, err := ApplyBytes(ctx, test, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte(`{"foo":"tok_68c70ddb0cf1e172e9f70dc23ebc0d6fa3ed4f3102ce430f22211f0ea5439389"}`)
+	if !bytes.Equal(token, expected) {
+		t.Errorf("expected %s, got %s", expected, token)
+	}
+
+	result, err := ApplyBytes(ctx, token, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(result, test) {
+		t.Errorf("expected %s, got %s", test, result)
+	}
+
+	// tokens that are not in the vault cannot be reversed
+	if _, err := ApplyBytes(ctx, []byte(`{"foo":"tok_unknown"}`), from); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func benchmarkTokenize(b *testing.B, applier procTokenize, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	capsule := config.NewCapsule()
+	b.Setenv("SUBSTATION_TOKENIZE_TEST_SECRET", "secret")
+
+	for _, test := range tokenizeTests {
+		proc, err := newProcTokenize(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkTokenize(b, proc, capsule)
+			},
+		)
+	}
+}
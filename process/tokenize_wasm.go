@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procTokenize struct {
+	process
+	Options procTokenizeOptions `json:"options"`
+}
+
+type procTokenizeOptions struct{}
+
+func newProcTokenize(ctx context.Context, cfg config.Config) (p procTokenize, err error) {
+	return procTokenize{}, fmt.Errorf("process: tokenize: %v", syscall.ENOSYS)
+}
+
+func (p procTokenize) String() string {
+	return toString(p)
+}
+
+func (p procTokenize) Close(ctx context.Context) error {
+	return fmt.Errorf("close: tokenize: %v", syscall.ENOSYS)
+}
+
+func (p procTokenize) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procTokenize) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: tokenize: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,324 @@
+package process
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+)
+
+// urlDefaultPorts maps URL schemes to their default ports. Default ports are
+// removed from URLs during normalization.
+var urlDefaultPorts = map[string]string{
+	"ftp":   "21",
+	"http":  "80",
+	"https": "443",
+	"ws":    "80",
+	"wss":   "443",
+}
+
+// urlDefangSchemes maps URL schemes to their defanged representation.
+var urlDefangSchemes = map[string]string{
+	"ftp":   "fxp",
+	"http":  "hxxp",
+	"https": "hxxps",
+}
+
+// urlRefangSchemes maps defanged URL schemes to their original value.
+var urlRefangSchemes = map[string]string{
+	"fxp":   "ftp",
+	"hxxp":  "http",
+	"hxxps": "https",
+}
+
+// urlRefangReplacer replaces common defanged substrings with their original
+// value. Schemes are not included because they are only restored at the start
+// of the URL.
+var urlRefangReplacer = strings.NewReplacer(
+	"[://]", "://",
+	"[:]", ":",
+	"[.]", ".",
+	"(.)", ".",
+	"{.}", ".",
+	"[dot]", ".",
+	"(dot)", ".",
+	"[@]", "@",
+)
+
+// url processes data by parsing and modifying Uniform Resource Locators
+// (URLs).
+//
+// This processor supports the data and object handling patterns.
+type procURL struct {
+	process
+	Options procURLOptions `json:"options"`
+}
+
+type procURLOptions struct {
+	// Type is the URL function applied to the data.
+	//
+	// Must be one of:
+	//
+	// - parse: URL is parsed into an object that contains the scheme,
+	// userinfo, host, port, path, query parameters, fragment, and
+	// registered domain
+	//
+	// - normalize: URL scheme and host are lowercased, default ports
+	// are removed, and the path and query are percent-encoded consistently
+	//
+	// - encode: data is percent-encoded for safe use in a URL
+	//
+	// - decode: data is decoded from percent-encoding
+	//
+	// - defang: URL is made non-clickable (e.g., http://example.com
+	// becomes hxxp://example[.]com)
+	//
+	// - refang: defanged URL is restored to its original value
+	Type string `json:"type"`
+}
+
+// Create a new URL processor.
+func newProcURL(ctx context.Context, cfg config.Config) (p procURL, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procURL{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procURL{}, err
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"parse",
+			"normalize",
+			"encode",
+			"decode",
+			"defang",
+			"refang",
+		},
+		p.Options.Type) {
+		return procURL{}, fmt.Errorf("process: url: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procURL{}, fmt.Errorf("process: url: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procURL) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procURL) Close(context.Context) error {
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procURL) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procURL) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key).String()
+
+		value, err := p.url(result)
+		if err != nil {
+			return capsule, fmt.Errorf("process: url: %v", err)
+		}
+
+		if err := capsule.Set(p.SetKey, value); err != nil {
+			return capsule, fmt.Errorf("process: url: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, err := p.url(string(capsule.Data()))
+		if err != nil {
+			return capsule, fmt.Errorf("process: url: %v", err)
+		}
+
+		switch v := value.(type) {
+		case string:
+			capsule.SetData([]byte(v))
+		default:
+			b, err := gojson.Marshal(v)
+			if err != nil {
+				return capsule, fmt.Errorf("process: url: %v", err)
+			}
+
+			capsule.SetData(b)
+		}
+
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: url: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+func (p procURL) url(s string) (interface{}, error) {
+	switch p.Options.Type {
+	case "parse":
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+
+		return urlParse(u), nil
+	case "normalize":
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+
+		return urlNormalize(u), nil
+	case "encode":
+		return url.QueryEscape(s), nil
+	case "decode":
+		return url.QueryUnescape(s)
+	case "defang":
+		return urlDefang(s), nil
+	case "refang":
+		return urlRefang(s), nil
+	default:
+		return nil, fmt.Errorf("type %s: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+}
+
+// urlParse returns an object that contains the components of a URL. Query
+// parameters that occur once are stored as strings and parameters that occur
+// more than once are stored as arrays.
+func urlParse(u *url.URL) map[string]interface{} {
+	query := make(map[string]interface{})
+	for k, v := range u.Query() {
+		if len(v) == 1 {
+			query[k] = v[0]
+			continue
+		}
+
+		query[k] = v
+	}
+
+	// registered domains do not exist for IP addresses
+	var domain string
+	if host := u.Hostname(); host != "" && net.ParseIP(host) == nil {
+		domain, _ = publicsuffix.EffectiveTLDPlusOne(host)
+	}
+
+	var userinfo string
+	if u.User != nil {
+		userinfo = u.User.String()
+	}
+
+	return map[string]interface{}{
+		"scheme":   u.Scheme,
+		"userinfo": userinfo,
+		"host":     u.Hostname(),
+		"port":     u.Port(),
+		"path":     u.Path,
+		"query":    query,
+		"fragment": u.Fragment,
+		"domain":   domain,
+	}
+}
+
+// urlNormalize returns a normalized URL. Normalization follows most of the
+// practices described in RFC 3986 (https://www.rfc-editor.org/rfc/rfc3986#section-6):
+//
+// - scheme and host are converted to lowercase
+//
+// - default ports are removed
+//
+// - empty paths are converted to "/"
+//
+// - path and query are percent-encoded consistently (query parameters are
+// also sorted by key)
+func urlNormalize(u *url.URL) string {
+	u.Scheme = strings.ToLower(u.Scheme)
+
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && urlDefaultPorts[u.Scheme] != port {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		// IPv6 addresses must be enclosed in brackets
+		host = "[" + host + "]"
+	}
+	u.Host = host
+
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+
+	// removing the raw path causes the path to be encoded using
+	// the standard library's canonical encoding
+	u.RawPath = ""
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode()
+	}
+
+	return u.String()
+}
+
+// urlDefang returns a defanged URL. Defanged URLs have their scheme and
+// host modified so that they cannot be accidentally opened.
+func urlDefang(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		// if the value cannot be parsed as a URL, then all dots are
+		// replaced (e.g., example.com becomes example[.]com)
+		return strings.ReplaceAll(s, ".", "[.]")
+	}
+
+	scheme := u.Scheme
+	if v, ok := urlDefangSchemes[strings.ToLower(scheme)]; ok {
+		scheme = v
+	}
+
+	// the host is replaced in the original string to avoid any changes
+	// that could be caused by re-encoding the URL
+	defanged := strings.Replace(s, u.Host, strings.ReplaceAll(u.Host, ".", "[.]"), 1)
+	defanged = strings.Replace(defanged, u.Scheme, scheme, 1)
+
+	return defanged
+}
+
+// urlRefang returns the original value of a defanged URL. The scheme is only
+// restored if it is at the start of the URL (e.g., hxxp://example[.]com/fxp
+// becomes http://example.com/fxp).
+func urlRefang(s string) string {
+	s = urlRefangReplacer.Replace(s)
+
+	i := strings.Index(s, ":")
+	if i == -1 {
+		return s
+	}
+
+	if v, ok := urlRefangSchemes[strings.ToLower(s[:i])]; ok {
+		return v + s[i:]
+	}
+
+	return s
+}
@@ -0,0 +1,281 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ua-parser/uap-go/uaparser"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/file"
+	"github.com/brexhq/substation/internal/kv"
+)
+
+// userAgent processes data by parsing user-agent strings into browser,
+// operating system (OS), and device information. Parsing relies on a regular
+// expression database that uses the uap-core format
+// (https://github.com/ua-parser/uap-core).
+//
+// Results are cached in memory using least recently used (LRU) eviction, so
+// repeated user-agent strings are not parsed more than once.
+//
+// This processor supports the data and object handling patterns.
+type procUserAgent struct {
+	process
+	Options procUserAgentOptions `json:"options"`
+
+	parser *uaparser.Parser
+	cache  kv.Storer
+}
+
+type procUserAgentOptions struct {
+	// Database contains the location of a uap-core regular expression
+	// database (regexes.yaml). This can be either a path on local disk,
+	// an HTTP(S) URL, or an AWS S3 URL.
+	//
+	// This is optional and defaults to the database bundled with the
+	// processor.
+	Database string `json:"database"`
+	// Capacity limits the number of parsed user-agent strings that are
+	// stored in the cache.
+	//
+	// This is optional and defaults to 1024 values.
+	Capacity int `json:"capacity"`
+}
+
+// Create a new user agent processor.
+func newProcUserAgent(ctx context.Context, cfg config.Config) (p procUserAgent, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procUserAgent{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procUserAgent{}, err
+	}
+
+	// validate data processing pattern
+	if (p.Key != "" && p.SetKey == "") ||
+		(p.Key == "" && p.SetKey != "") {
+		return procUserAgent{}, fmt.Errorf("process: user_agent: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+	}
+
+	if p.Options.Database == "" {
+		p.parser = uaparser.NewFromSaved()
+	} else {
+		path, err := file.Get(ctx, p.Options.Database)
+		defer os.Remove(path)
+
+		if err != nil {
+			return procUserAgent{}, fmt.Errorf("process: user_agent: %v", err)
+		}
+
+		p.parser, err = uaparser.New(path)
+		if err != nil {
+			return procUserAgent{}, fmt.Errorf("process: user_agent: database %s: %v", p.Options.Database, err)
+		}
+	}
+
+	if p.Options.Capacity == 0 {
+		p.Options.Capacity = 1024
+	}
+
+	// the cache is not shared with other processors because each
+	// processor may use a different database.
+	p.cache, err = kv.New(config.Config{
+		Type: "memory",
+		Settings: map[string]interface{}{
+			"capacity": p.Options.Capacity,
+		},
+	})
+	if err != nil {
+		return procUserAgent{}, fmt.Errorf("process: user_agent: %v", err)
+	}
+
+	if err := p.cache.Setup(ctx); err != nil {
+		return procUserAgent{}, fmt.Errorf("process: user_agent: %v", err)
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procUserAgent) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procUserAgent) Close(context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if err := p.cache.Close(); err != nil {
+		return fmt.Errorf("close: user_agent: %v", err)
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procUserAgent) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procUserAgent) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	// JSON processing
+	if p.Key != "" && p.SetKey != "" {
+		result := capsule.Get(p.Key).String()
+
+		value, err := p.parse(ctx, result)
+		if err != nil {
+			return capsule, fmt.Errorf("process: user_agent: %v", err)
+		}
+
+		if err := capsule.Set(p.SetKey, value); err != nil {
+			return capsule, fmt.Errorf("process: user_agent: %v", err)
+		}
+
+		return capsule, nil
+	}
+
+	// data processing
+	if p.Key == "" && p.SetKey == "" {
+		value, err := p.parse(ctx, string(capsule.Data()))
+		if err != nil {
+			return capsule, fmt.Errorf("process: user_agent: %v", err)
+		}
+
+		capsule.SetData(value)
+		return capsule, nil
+	}
+
+	return capsule, fmt.Errorf("process: user_agent: key %s set_key %s: %v", p.Key, p.SetKey, errInvalidDataPattern)
+}
+
+type userAgentRecord struct {
+	Browser userAgentBrowser `json:"browser"`
+	OS      userAgentOS      `json:"os"`
+	Device  userAgentDevice  `json:"device"`
+}
+
+type userAgentBrowser struct {
+	Family  string `json:"family"`
+	Major   string `json:"major"`
+	Minor   string `json:"minor"`
+	Patch   string `json:"patch"`
+	Version string `json:"version"`
+}
+
+type userAgentOS struct {
+	Family     string `json:"family"`
+	Major      string `json:"major"`
+	Minor      string `json:"minor"`
+	Patch      string `json:"patch"`
+	PatchMinor string `json:"patch_minor"`
+	Version    string `json:"version"`
+}
+
+type userAgentDevice struct {
+	Family string `json:"family"`
+	Brand  string `json:"brand"`
+	Model  string `json:"model"`
+	Type   string `json:"type"`
+}
+
+// parse returns the parsed user-agent as a JSON object. Parsed values are
+// retrieved from the cache if they exist, otherwise they are put into the
+// cache.
+func (p procUserAgent) parse(ctx context.Context, ua string) ([]byte, error) {
+	cached, err := p.cache.Get(ctx, ua)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := cached.([]byte); ok {
+		return b, nil
+	}
+
+	client := p.parser.Parse(ua)
+	record := userAgentRecord{
+		Browser: userAgentBrowser{
+			Family:  client.UserAgent.Family,
+			Major:   client.UserAgent.Major,
+			Minor:   client.UserAgent.Minor,
+			Patch:   client.UserAgent.Patch,
+			Version: client.UserAgent.ToVersionString(),
+		},
+		OS: userAgentOS{
+			Family:     client.Os.Family,
+			Major:      client.Os.Major,
+			Minor:      client.Os.Minor,
+			Patch:      client.Os.Patch,
+			PatchMinor: client.Os.PatchMinor,
+			Version:    client.Os.ToVersionString(),
+		},
+		Device: userAgentDevice{
+			Family: client.Device.Family,
+			Brand:  client.Device.Brand,
+			Model:  client.Device.Model,
+			Type:   userAgentDeviceType(ua, client),
+		},
+	}
+
+	b, err := gojson.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.cache.Set(ctx, ua, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// userAgentDeviceType returns the type of device that produced a user-agent.
+// The uap-core database does not categorize devices, so the type is inferred
+// from the parsed device, the parsed OS, and common user-agent tokens.
+//
+// The type is one of:
+//
+// - bot
+//
+// - tablet
+//
+// - mobile
+//
+// - desktop
+//
+// - other
+func userAgentDeviceType(ua string, client *uaparser.Client) string {
+	if client.Device.Family == "Spider" {
+		return "bot"
+	}
+
+	switch {
+	case client.Device.Family == "iPad",
+		strings.Contains(ua, "Tablet"),
+		client.Os.Family == "Android" && !strings.Contains(ua, "Mobile"):
+		return "tablet"
+	case strings.Contains(ua, "Mobi"),
+		client.Os.Family == "iOS",
+		client.Device.Family == "iPhone":
+		return "mobile"
+	}
+
+	switch client.Os.Family {
+	case "Windows", "Mac OS X", "Linux", "Ubuntu", "Fedora", "Chrome OS", "FreeBSD", "OpenBSD":
+		return "desktop"
+	}
+
+	return "other"
+}
@@ -0,0 +1,106 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procUserAgent{}
+	_ Batcher = procUserAgent{}
+)
+
+var userAgentTests = []struct {
+	name     string
+	cfg      config.Config
+	test     []byte
+	expected []byte
+	err      error
+}{
+	{
+		"JSON",
+		config.Config{
+			Type: "user_agent",
+			Settings: map[string]interface{}{
+				"key":     "ua",
+				"set_key": "ua",
+			},
+		},
+		[]byte(`{"ua":"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/112.0.0.0 Safari/537.36"}`),
+		[]byte(`{"ua":{"browser":{"family":"Chrome","major":"112","minor":"0","patch":"0","version":"112.0.0"},"os":{"family":"Mac OS X","major":"10","minor":"15","patch":"7","patch_minor":"","version":"10.15.7"},"device":{"family":"Mac","brand":"Apple","model":"Mac","type":"desktop"}}}`),
+		nil,
+	},
+	{
+		"data",
+		config.Config{
+			Type: "user_agent",
+		},
+		[]byte(`Mozilla/5.0 (iPhone; CPU iPhone OS 16_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.4 Mobile/15E148 Safari/604.1`),
+		[]byte(`{"browser":{"family":"Mobile Safari","major":"16","minor":"4","patch":"","version":"16.4"},"os":{"family":"iOS","major":"16","minor":"4","patch":"","patch_minor":"","version":"16.4"},"device":{"family":"iPhone","brand":"Apple","model":"iPhone","type":"mobile"}}`),
+		nil,
+	},
+	{
+		"data bot",
+		config.Config{
+			Type: "user_agent",
+		},
+		[]byte(`Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)`),
+		[]byte(`{"browser":{"family":"Googlebot","major":"2","minor":"1","patch":"","version":"2.1"},"os":{"family":"Other","major":"","minor":"","patch":"","patch_minor":"","version":""},"device":{"family":"Spider","brand":"Spider","model":"Desktop","type":"bot"}}`),
+		nil,
+	},
+}
+
+func TestUserAgent(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range userAgentTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcUserAgent(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the second pass is served from the cache
+			for i := 0; i < 2; i++ {
+				result, err := proc.Apply(ctx, capsule)
+				if err != nil {
+					t.Error(err)
+				}
+
+				if !bytes.Equal(result.Data(), test.expected) {
+					t.Errorf("expected %s, got %s", test.expected, result.Data())
+				}
+			}
+		})
+	}
+}
+
+func benchmarkUserAgent(b *testing.B, applier procUserAgent, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkUserAgent(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range userAgentTests {
+		proc, err := newProcUserAgent(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkUserAgent(b, proc, capsule)
+			},
+		)
+	}
+}
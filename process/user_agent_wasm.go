@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procUserAgent struct {
+	process
+	Options procUserAgentOptions `json:"options"`
+}
+
+type procUserAgentOptions struct{}
+
+func newProcUserAgent(ctx context.Context, cfg config.Config) (p procUserAgent, err error) {
+	return procUserAgent{}, fmt.Errorf("process: user_agent: %v", syscall.ENOSYS)
+}
+
+func (p procUserAgent) String() string {
+	return toString(p)
+}
+
+func (p procUserAgent) Close(ctx context.Context) error {
+	return fmt.Errorf("close: user_agent: %v", syscall.ENOSYS)
+}
+
+func (p procUserAgent) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procUserAgent) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: user_agent: %v", syscall.ENOSYS)
+}
@@ -0,0 +1,255 @@
+//go:build !wasm
+
+package process
+
+import (
+	"context"
+	gojson "encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/file"
+)
+
+// errWasmPlugin is returned when a WebAssembly plugin returns an error but
+// does not provide an error message.
+var errWasmPlugin = fmt.Errorf("plugin returned an error")
+
+// wasm processes data by calling a WebAssembly (WASM) plugin. Plugins are run
+// in a sandboxed, pure Go runtime (https://wazero.io/) and can be written in
+// any language that compiles to WebAssembly. Plugins have access to WASI
+// (https://wasi.dev/), but cannot access the filesystem, network, or
+// environment variables.
+//
+// Plugins are instantiated for each capsule, which isolates capsules from each
+// other, and must implement the ABI described in examples/wasm/README.md.
+//
+// This processor supports the data and object handling patterns.
+type procWasm struct {
+	process
+	Options procWasmOptions `json:"options"`
+
+	runtime wazero.Runtime
+	module  wazero.CompiledModule
+}
+
+type procWasmOptions struct {
+	// Module is the location of the WebAssembly module. This can be either a
+	// path on local disk, an HTTP(S) URL, or an AWS S3 URL.
+	Module string `json:"module"`
+	// MaxMemory is the maximum amount of memory (in megabytes) that the
+	// plugin can use.
+	//
+	// This is optional and defaults to 64 megabytes.
+	MaxMemory int `json:"max_memory"`
+	// Timeout is the amount of time to wait (in milliseconds) for the plugin
+	// to process each capsule.
+	//
+	// This is optional and defaults to 1000 milliseconds (1 second).
+	Timeout int `json:"timeout"`
+}
+
+// wasmStateKey is the context key for the state of a plugin call.
+type wasmStateKey struct{}
+
+// wasmState stores the results of a plugin call.
+type wasmState struct {
+	data     []byte
+	metadata []byte
+	err      []byte
+}
+
+// Create a new wasm processor.
+func newProcWasm(ctx context.Context, cfg config.Config) (p procWasm, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procWasm{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procWasm{}, err
+	}
+
+	// error early if required options are missing
+	if p.Options.Module == "" {
+		return procWasm{}, fmt.Errorf("process: wasm: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.MaxMemory == 0 {
+		p.Options.MaxMemory = 64
+	}
+
+	if p.Options.Timeout == 0 {
+		p.Options.Timeout = 1000
+	}
+
+	path, err := file.Get(ctx, p.Options.Module)
+	defer os.Remove(path)
+
+	if err != nil {
+		return procWasm{}, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	bin, err := os.ReadFile(path)
+	if err != nil {
+		return procWasm{}, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	// WebAssembly memory is allocated in 64 KiB pages
+	rcfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(p.Options.MaxMemory * 16)).
+		WithCloseOnContextDone(true)
+
+	p.runtime = wazero.NewRuntimeWithConfig(ctx, rcfg)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return procWasm{}, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	_, err = p.runtime.NewHostModuleBuilder("substation").
+		NewFunctionBuilder().WithFunc(wasmHostFunc(func(s *wasmState, b []byte) { s.data = b })).Export("set_data").
+		NewFunctionBuilder().WithFunc(wasmHostFunc(func(s *wasmState, b []byte) { s.metadata = b })).Export("set_metadata").
+		NewFunctionBuilder().WithFunc(wasmHostFunc(func(s *wasmState, b []byte) { s.err = b })).Export("set_error").
+		Instantiate(ctx)
+	if err != nil {
+		return procWasm{}, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	p.module, err = p.runtime.CompileModule(ctx, bin)
+	if err != nil {
+		return procWasm{}, fmt.Errorf("process: wasm: module %s: %v", p.Options.Module, err)
+	}
+
+	for _, name := range []string{"alloc", "process"} {
+		if _, ok := p.module.ExportedFunctions()[name]; !ok {
+			return procWasm{}, fmt.Errorf("process: wasm: module %s: function %s: %v", p.Options.Module, name, errors.ErrMissingRequiredOption)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procWasm) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procWasm) Close(ctx context.Context) error {
+	if p.IgnoreClose {
+		return nil
+	}
+
+	if err := p.runtime.Close(ctx); err != nil {
+		return fmt.Errorf("close: wasm: %v", err)
+	}
+
+	return nil
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procWasm) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+// Apply processes a capsule with the processor.
+func (p procWasm) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	state := &wasmState{}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(p.Options.Timeout)*time.Millisecond)
+	defer cancel()
+	ctx = context.WithValue(ctx, wasmStateKey{}, state)
+
+	// modules are anonymous so that they can be instantiated concurrently
+	mod, err := p.runtime.InstantiateModule(ctx, p.module,
+		wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize"))
+	if err != nil {
+		return capsule, fmt.Errorf("process: wasm: %v", err)
+	}
+	defer mod.Close(ctx)
+
+	dataPtr, err := wasmWrite(ctx, mod, capsule.Data())
+	if err != nil {
+		return capsule, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	metaPtr, err := wasmWrite(ctx, mod, capsule.Metadata())
+	if err != nil {
+		return capsule, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	res, err := mod.ExportedFunction("process").Call(ctx,
+		uint64(dataPtr), uint64(len(capsule.Data())),
+		uint64(metaPtr), uint64(len(capsule.Metadata())),
+	)
+	if err != nil {
+		return capsule, fmt.Errorf("process: wasm: %v", err)
+	}
+
+	if len(res) != 1 || res[0] != 0 {
+		if state.err != nil {
+			return capsule, fmt.Errorf("process: wasm: %s", state.err)
+		}
+
+		return capsule, fmt.Errorf("process: wasm: %v", errWasmPlugin)
+	}
+
+	if state.data != nil {
+		capsule.SetData(state.data)
+	}
+
+	if state.metadata != nil {
+		if _, err := capsule.SetMetadata(gojson.RawMessage(state.metadata)); err != nil {
+			return capsule, fmt.Errorf("process: wasm: %v", err)
+		}
+	}
+
+	return capsule, nil
+}
+
+// wasmWrite allocates memory in a module and writes bytes to it. The
+// pointer to the bytes is returned.
+func wasmWrite(ctx context.Context, mod api.Module, b []byte) (uint32, error) {
+	res, err := mod.ExportedFunction("alloc").Call(ctx, uint64(len(b)))
+	if err != nil {
+		return 0, err
+	}
+
+	ptr := uint32(res[0])
+	if !mod.Memory().Write(ptr, b) {
+		return 0, fmt.Errorf("alloc: pointer %d length %d is out of range", ptr, len(b))
+	}
+
+	return ptr, nil
+}
+
+// wasmHostFunc returns a host function that reads bytes from a module and
+// stores them in the state of the plugin call.
+func wasmHostFunc(fn func(*wasmState, []byte)) func(context.Context, api.Module, uint32, uint32) {
+	return func(ctx context.Context, mod api.Module, ptr, size uint32) {
+		state, ok := ctx.Value(wasmStateKey{}).(*wasmState)
+		if !ok {
+			return
+		}
+
+		b, ok := mod.Memory().Read(ptr, size)
+		if !ok {
+			return
+		}
+
+		// the module's memory is released after the call, so the bytes
+		// are copied
+		c := make([]byte, len(b))
+		copy(c, b)
+
+		fn(state, c)
+	}
+}
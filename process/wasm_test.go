@@ -0,0 +1,223 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var (
+	_ Applier = procWasm{}
+	_ Batcher = procWasm{}
+)
+
+// wasmEcho is the body of a process function that returns an error if the
+// data is empty, otherwise it returns the data unchanged.
+var wasmEcho = []byte{
+	// if data_len == 0 { set_error(0, 5); return 1 }
+	0x20, 0x01, 0x45, 0x04, 0x40,
+	0x41, 0x00, 0x41, 0x05, 0x10, 0x01,
+	0x41, 0x01, 0x0f, 0x0b,
+	// set_data(data_ptr, data_len); return 0
+	0x20, 0x00, 0x20, 0x01, 0x10, 0x00,
+	0x41, 0x00, 0x0b,
+}
+
+// wasmLoop is the body of a process function that never returns.
+var wasmLoop = []byte{
+	// loop { br 0 }
+	0x03, 0x40, 0x0c, 0x00, 0x0b,
+	0x41, 0x00, 0x0b,
+}
+
+// wasmModule returns a WebAssembly module that implements the plugin ABI
+// with a process function. The module contains the string "error" at
+// address 0.
+func wasmModule(process []byte) []byte {
+	uleb := func(n int) []byte {
+		var b []byte
+		for {
+			c := byte(n & 0x7f)
+			n >>= 7
+			if n != 0 {
+				c |= 0x80
+			}
+
+			b = append(b, c)
+			if n == 0 {
+				return b
+			}
+		}
+	}
+
+	name := func(s string) []byte {
+		return append(uleb(len(s)), s...)
+	}
+
+	section := func(id byte, content ...[]byte) []byte {
+		c := bytes.Join(content, nil)
+		return append(append([]byte{id}, uleb(len(c))...), c...)
+	}
+
+	alloc := []byte{
+		// ptr = heap; heap += size; return ptr
+		0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b,
+	}
+
+	code := func(body []byte) []byte {
+		// functions have no locals
+		b := append([]byte{0x00}, body...)
+		return append(uleb(len(b)), b...)
+	}
+
+	return bytes.Join([][]byte{
+		{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(1, []byte{0x03},
+			// (i32, i32) -> ()
+			[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},
+			// (i32) -> (i32)
+			[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},
+			// (i32, i32, i32, i32) -> (i32)
+			[]byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f},
+		),
+		section(2, []byte{0x02},
+			name("substation"), name("set_data"), []byte{0x00, 0x00},
+			name("substation"), name("set_error"), []byte{0x00, 0x00},
+		),
+		section(3, []byte{0x02, 0x01, 0x02}),
+		section(5, []byte{0x01, 0x00, 0x01}),
+		// the heap starts at address 1024
+		section(6, []byte{0x01, 0x7f, 0x01, 0x41, 0x80, 0x08, 0x0b}),
+		section(7, []byte{0x03},
+			name("memory"), []byte{0x02, 0x00},
+			name("alloc"), []byte{0x00, 0x02},
+			name("process"), []byte{0x00, 0x03},
+		),
+		section(10, []byte{0x02}, code(alloc), code(process)),
+		section(11, []byte{0x01, 0x00, 0x41, 0x00, 0x0b}, name("error")),
+	}, nil)
+}
+
+var wasmTests = []struct {
+	name     string
+	process  []byte
+	options  map[string]interface{}
+	test     []byte
+	expected []byte
+	err      string
+}{
+	{
+		"echo",
+		wasmEcho,
+		nil,
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`{"foo":"bar"}`),
+		"",
+	},
+	{
+		"error",
+		wasmEcho,
+		nil,
+		[]byte(``),
+		[]byte(``),
+		"process: wasm: error",
+	},
+	{
+		"timeout",
+		wasmLoop,
+		map[string]interface{}{
+			"timeout": 100,
+		},
+		[]byte(`{"foo":"bar"}`),
+		[]byte(`{"foo":"bar"}`),
+		"deadline exceeded",
+	},
+}
+
+func wasmConfig(t testing.TB, process []byte, options map[string]interface{}) config.Config {
+	path := filepath.Join(t.TempDir(), "plugin.wasm")
+	if err := os.WriteFile(path, wasmModule(process), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := map[string]interface{}{
+		"module": path,
+	}
+	for k, v := range options {
+		opts[k] = v
+	}
+
+	return config.Config{
+		Type: "wasm",
+		Settings: map[string]interface{}{
+			"options": opts,
+		},
+	}
+}
+
+func TestWasm(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range wasmTests {
+		t.Run(test.name, func(t *testing.T) {
+			capsule.SetData(test.test)
+
+			proc, err := newProcWasm(ctx, wasmConfig(t, test.process, test.options))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer proc.Close(ctx)
+
+			result, err := proc.Apply(ctx, capsule)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("expected %s, got %v", test.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(result.Data(), test.expected) {
+				t.Errorf("expected %s, got %s", test.expected, result.Data())
+			}
+		})
+	}
+}
+
+func benchmarkWasm(b *testing.B, applier procWasm, test config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = applier.Apply(ctx, test)
+	}
+}
+
+func BenchmarkWasm(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range wasmTests {
+		if test.err != "" {
+			continue
+		}
+
+		proc, err := newProcWasm(context.TODO(), wasmConfig(b, test.process, test.options))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				capsule.SetData(test.test)
+				benchmarkWasm(b, proc, capsule)
+			},
+		)
+	}
+}
@@ -0,0 +1,38 @@
+//go:build wasm
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	"github.com/brexhq/substation/config"
+)
+
+type procWasm struct {
+	process
+	Options procWasmOptions `json:"options"`
+}
+
+type procWasmOptions struct{}
+
+func newProcWasm(ctx context.Context, cfg config.Config) (p procWasm, err error) {
+	return procWasm{}, fmt.Errorf("process: wasm: %v", syscall.ENOSYS)
+}
+
+func (p procWasm) String() string {
+	return toString(p)
+}
+
+func (p procWasm) Close(ctx context.Context) error {
+	return fmt.Errorf("close: wasm: %v", syscall.ENOSYS)
+}
+
+func (p procWasm) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	return batchApply(ctx, capsules, p, p.operator)
+}
+
+func (p procWasm) Apply(ctx context.Context, capsule config.Capsule) (config.Capsule, error) {
+	return capsule, fmt.Errorf("process: wasm: %v", syscall.ENOSYS)
+}
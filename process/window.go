@@ -0,0 +1,413 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/brexhq/substation/condition"
+	"github.com/brexhq/substation/config"
+	"github.com/brexhq/substation/internal/errors"
+	"github.com/brexhq/substation/internal/json"
+)
+
+// errWindowMissingTime is returned when the window processor cannot find the
+// event time in an object.
+var errWindowMissingTime = fmt.Errorf("missing event time")
+
+// window processes data by grouping objects into time windows and summarizing
+// each group with statistics. Windows are based on the event time of each
+// object and are either tumbling (fixed size, non-overlapping) or sliding
+// (fixed size, overlapping). One summary object is emitted for each window
+// and group in the batch; data that does not match the processor's condition
+// is not summarized and is emitted unchanged.
+//
+// Summary objects contain the window, the values of the group keys, the number
+// of objects in the window, and each configured statistic:
+//
+//	{"window":{"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:01:00Z"},"group":{"host":"foo"},"count":2,"bytes_sum":10}
+//
+// This processor supports the object handling pattern.
+type procWindow struct {
+	process
+	Options procWindowOptions `json:"options"`
+}
+
+type procWindowOptions struct {
+	// Type determines the type of window.
+	//
+	// Must be one of:
+	//
+	// - tumbling: each object belongs to one window
+	//
+	// - sliding: each object belongs to every window that contains its
+	// event time; windows start every Step seconds
+	Type string `json:"type"`
+	// Size is the length of the window in seconds.
+	Size int `json:"size"`
+	// Step is the number of seconds between the start of sliding windows.
+	//
+	// This is required for sliding windows.
+	Step int `json:"step"`
+	// TimeKey retrieves the event time from an object.
+	TimeKey string `json:"time_key"`
+	// TimeFormat is the time format of the event time.
+	//
+	// Must be one of:
+	//
+	// - pattern-based layouts (https://gobyexample.com/time-formatting-parsing)
+	//
+	// - unix: epoch (supports fractions of a second)
+	//
+	// - unix_milli: epoch milliseconds
+	//
+	// If the event time does not exist or cannot be parsed, then the
+	// processor returns an error.
+	//
+	// This is optional and defaults to RFC3339.
+	TimeFormat string `json:"time_format"`
+	// GroupKeys retrieve values from an object that are used to group
+	// objects within a window.
+	//
+	// This is optional and defaults to grouping all objects in a window.
+	GroupKeys []string `json:"group_keys"`
+	// Statistics are calculated for each group in a window.
+	//
+	// This is optional and defaults to only counting objects.
+	Statistics []procWindowStatistic `json:"statistics"`
+}
+
+type procWindowStatistic struct {
+	// Key retrieves a value from an object that is used to calculate the
+	// statistic.
+	Key string `json:"key"`
+	// SetKey inserts the statistic into the summary object.
+	SetKey string `json:"set_key"`
+	// Type is the type of statistic.
+	//
+	// Must be one of:
+	//
+	// - sum
+	//
+	// - min
+	//
+	// - max
+	//
+	// - avg
+	//
+	// - distinct_count
+	//
+	// - percentile
+	Type string `json:"type"`
+	// Percentile is the percentile (between 0 and 100) that is calculated
+	// using the nearest-rank method.
+	//
+	// This is required if the type is percentile.
+	Percentile float64 `json:"percentile"`
+}
+
+// Create a new window processor.
+func newProcWindow(ctx context.Context, cfg config.Config) (p procWindow, err error) {
+	if err = config.Decode(cfg.Settings, &p); err != nil {
+		return procWindow{}, err
+	}
+
+	p.operator, err = condition.NewOperator(ctx, p.Condition)
+	if err != nil {
+		return procWindow{}, err
+	}
+
+	//  validate option.type
+	if !slices.Contains(
+		[]string{
+			"tumbling",
+			"sliding",
+		},
+		p.Options.Type) {
+		return procWindow{}, fmt.Errorf("process: window: type %q: %v", p.Options.Type, errors.ErrInvalidOption)
+	}
+
+	// error early if required options are missing
+	if p.Options.Size <= 0 || p.Options.TimeKey == "" {
+		return procWindow{}, fmt.Errorf("process: window: options %+v: %v", p.Options, errors.ErrMissingRequiredOption)
+	}
+
+	if p.Options.Type == "tumbling" {
+		p.Options.Step = p.Options.Size
+	}
+
+	if p.Options.Step <= 0 || p.Options.Step > p.Options.Size {
+		return procWindow{}, fmt.Errorf("process: window: step %d: %v", p.Options.Step, errors.ErrInvalidOption)
+	}
+
+	if p.Options.TimeFormat == "" {
+		p.Options.TimeFormat = time.RFC3339
+	}
+
+	for _, s := range p.Options.Statistics {
+		//  validate option.statistics.type
+		if !slices.Contains(
+			[]string{
+				"sum",
+				"min",
+				"max",
+				"avg",
+				"distinct_count",
+				"percentile",
+			},
+			s.Type) {
+			return procWindow{}, fmt.Errorf("process: window: statistic type %q: %v", s.Type, errors.ErrInvalidOption)
+		}
+
+		if s.Key == "" || s.SetKey == "" {
+			return procWindow{}, fmt.Errorf("process: window: statistic %+v: %v", s, errors.ErrMissingRequiredOption)
+		}
+
+		if s.Type == "percentile" && (s.Percentile <= 0 || s.Percentile > 100) {
+			return procWindow{}, fmt.Errorf("process: window: percentile %v: %v", s.Percentile, errors.ErrInvalidOption)
+		}
+	}
+
+	return p, nil
+}
+
+// String returns the processor settings as an object.
+func (p procWindow) String() string {
+	return toString(p)
+}
+
+// Closes resources opened by the processor.
+func (p procWindow) Close(context.Context) error {
+	return nil
+}
+
+// windowGroup contains the objects in a window that share group values.
+type windowGroup struct {
+	start   time.Time
+	group   []json.Result
+	objects []config.Capsule
+}
+
+// Batch processes one or more capsules with the processor. Conditions are
+// optionally applied to the data to enable processing.
+func (p procWindow) Batch(ctx context.Context, capsules ...config.Capsule) ([]config.Capsule, error) {
+	size := time.Duration(p.Options.Size) * time.Second
+	step := time.Duration(p.Options.Step) * time.Second
+
+	// order is used to emit summaries in the order that groups were
+	// first seen within each window
+	var order []string
+	groups := make(map[string]*windowGroup)
+
+	newCapsules := newBatch(&capsules)
+	for _, capsule := range capsules {
+		ok, err := p.operator.Operate(ctx, capsule)
+		if err != nil {
+			return nil, fmt.Errorf("process: window: %v", err)
+		}
+
+		if !ok {
+			newCapsules = append(newCapsules, capsule)
+			continue
+		}
+
+		ts, err := windowTime(capsule.Get(p.Options.TimeKey), p.Options.TimeFormat)
+		if err != nil {
+			return nil, fmt.Errorf("process: window: %v", err)
+		}
+
+		var values []json.Result
+		var sig strings.Builder
+		for _, key := range p.Options.GroupKeys {
+			res := capsule.Get(key)
+			values = append(values, res)
+			sig.WriteString(res.Raw)
+			sig.WriteByte(0)
+		}
+
+		// the last window that contains the event time starts at the
+		// nearest step (aligned to the Unix epoch) before it, and
+		// earlier windows are found by subtracting the step until the
+		// window no longer contains the event time
+		nanos := ts.UnixNano()
+		last := time.Unix(0, nanos-nanos%int64(step)).UTC()
+		if nanos%int64(step) < 0 {
+			last = last.Add(-step)
+		}
+		for start := last; ts.Before(start.Add(size)); start = start.Add(-step) {
+			key := fmt.Sprint(start.UnixNano(), "/", sig.String())
+			if _, ok := groups[key]; !ok {
+				groups[key] = &windowGroup{start: start, group: values}
+				order = append(order, key)
+			}
+
+			groups[key].objects = append(groups[key].objects, capsule)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return groups[order[i]].start.Before(groups[order[j]].start)
+	})
+
+	for _, key := range order {
+		value, err := p.summarize(groups[key], size)
+		if err != nil {
+			return nil, fmt.Errorf("process: window: %v", err)
+		}
+
+		newCapsule := config.NewCapsule()
+		newCapsule.SetData(value)
+		newCapsules = append(newCapsules, newCapsule)
+	}
+
+	return newCapsules, nil
+}
+
+// summarize returns the summary object for a group.
+func (p procWindow) summarize(g *windowGroup, size time.Duration) ([]byte, error) {
+	var value []byte
+	var err error
+
+	value, err = json.Set(value, "window.start", g.start.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err = json.Set(value, "window.end", g.start.Add(size).UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range p.Options.GroupKeys {
+		value, err = json.SetRaw(value, "group."+key, windowRaw(g.group[i]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	value, err = json.Set(value, "count", len(g.objects))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range p.Options.Statistics {
+		value, err = json.Set(value, s.SetKey, windowStatistic(s, g.objects))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// windowRaw returns the raw JSON of a result. Values that do not exist are
+// converted to null.
+func windowRaw(res json.Result) string {
+	if !res.Exists() {
+		return "null"
+	}
+
+	return res.Raw
+}
+
+// windowStatistic calculates a statistic for a group of objects. Values that
+// do not exist are ignored, and statistics that cannot be calculated (e.g.,
+// the average of zero values) are null.
+func windowStatistic(s procWindowStatistic, objects []config.Capsule) interface{} {
+	if s.Type == "distinct_count" {
+		distinct := make(map[string]struct{})
+		for _, obj := range objects {
+			if res := obj.Get(s.Key); res.Exists() {
+				distinct[res.String()] = struct{}{}
+			}
+		}
+
+		return len(distinct)
+	}
+
+	var values []float64
+	for _, obj := range objects {
+		if res := obj.Get(s.Key); res.Exists() {
+			values = append(values, res.Float())
+		}
+	}
+
+	if len(values) == 0 {
+		if s.Type == "sum" {
+			return 0
+		}
+
+		return nil
+	}
+
+	switch s.Type {
+	case "sum", "avg":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+
+		if s.Type == "avg" {
+			return sum / float64(len(values))
+		}
+
+		return sum
+	case "min":
+		min := values[0]
+		for _, v := range values[1:] {
+			min = math.Min(min, v)
+		}
+
+		return min
+	case "max":
+		max := values[0]
+		for _, v := range values[1:] {
+			max = math.Max(max, v)
+		}
+
+		return max
+	case "percentile":
+		sort.Float64s(values)
+		rank := int(math.Ceil(s.Percentile / 100 * float64(len(values))))
+		if rank < 1 {
+			rank = 1
+		}
+
+		return values[rank-1]
+	default:
+		return nil
+	}
+}
+
+// windowTime returns the time of a value in a time format. An error is
+// returned if the value does not exist or, for epoch formats, is not a number.
+func windowTime(res json.Result, format string) (time.Time, error) {
+	if !res.Exists() {
+		return time.Time{}, fmt.Errorf("time_format %s: %v", format, errWindowMissingTime)
+	}
+
+	if (format == "unix" || format == "unix_milli") && !timeIsNumber(res) {
+		return time.Time{}, fmt.Errorf("time_format %s: %q is not a number", format, res.String())
+	}
+
+	switch format {
+	case "unix":
+		secs := math.Floor(res.Float())
+		nanos := math.Round((res.Float() - secs) * 1000000000)
+		return time.Unix(int64(secs), int64(nanos)).UTC(), nil
+	case "unix_milli":
+		return time.UnixMilli(res.Int()).UTC(), nil
+	default:
+		ts, err := time.Parse(format, res.String())
+		if err != nil {
+			return time.Time{}, fmt.Errorf("time_format %s: %v", format, err)
+		}
+
+		return ts.UTC(), nil
+	}
+}
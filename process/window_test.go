@@ -0,0 +1,206 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/brexhq/substation/config"
+)
+
+var _ Batcher = procWindow{}
+
+var windowTests = []struct {
+	name     string
+	cfg      config.Config
+	test     [][]byte
+	expected [][]byte
+	err      error
+}{
+	{
+		"tumbling",
+		config.Config{
+			Type: "window",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type":       "tumbling",
+					"size":       60,
+					"time_key":   "ts",
+					"group_keys": []string{"host"},
+					"statistics": []interface{}{
+						map[string]interface{}{
+							"key":     "bytes",
+							"set_key": "bytes_sum",
+							"type":    "sum",
+						},
+						map[string]interface{}{
+							"key":     "bytes",
+							"set_key": "bytes_max",
+							"type":    "max",
+						},
+						map[string]interface{}{
+							"key":     "user",
+							"set_key": "users",
+							"type":    "distinct_count",
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			[]byte(`{"ts":"2023-01-01T00:00:10Z","host":"foo","bytes":10,"user":"a"}`),
+			[]byte(`{"ts":"2023-01-01T00:00:20Z","host":"bar","bytes":5,"user":"a"}`),
+			[]byte(`{"ts":"2023-01-01T00:00:30Z","host":"foo","bytes":20,"user":"a"}`),
+			[]byte(`{"ts":"2023-01-01T00:01:10Z","host":"foo","bytes":1,"user":"b"}`),
+			[]byte(`{"ts":"2023-01-01T00:00:40Z","host":"foo","bytes":30,"user":"b"}`),
+		},
+		[][]byte{
+			[]byte(`{"window":{"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:01:00Z"},"group":{"host":"foo"},"count":3,"bytes_sum":60,"bytes_max":30,"users":2}`),
+			[]byte(`{"window":{"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:01:00Z"},"group":{"host":"bar"},"count":1,"bytes_sum":5,"bytes_max":5,"users":1}`),
+			[]byte(`{"window":{"start":"2023-01-01T00:01:00Z","end":"2023-01-01T00:02:00Z"},"group":{"host":"foo"},"count":1,"bytes_sum":1,"bytes_max":1,"users":1}`),
+		},
+		nil,
+	},
+	{
+		"sliding",
+		config.Config{
+			Type: "window",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type":        "sliding",
+					"size":        60,
+					"step":        30,
+					"time_key":    "ts",
+					"time_format": "unix",
+					"statistics": []interface{}{
+						map[string]interface{}{
+							"key":     "latency",
+							"set_key": "latency_avg",
+							"type":    "avg",
+						},
+						map[string]interface{}{
+							"key":        "latency",
+							"set_key":    "latency_p50",
+							"type":       "percentile",
+							"percentile": 50,
+						},
+					},
+				},
+			},
+		},
+		[][]byte{
+			// 2023-01-01T00:00:10Z
+			[]byte(`{"ts":1672531210,"latency":1}`),
+			// 2023-01-01T00:00:40Z
+			[]byte(`{"ts":1672531240,"latency":3}`),
+			// 2023-01-01T00:00:50Z
+			[]byte(`{"ts":1672531250,"latency":8}`),
+		},
+		[][]byte{
+			[]byte(`{"window":{"start":"2022-12-31T23:59:30Z","end":"2023-01-01T00:00:30Z"},"count":1,"latency_avg":1,"latency_p50":1}`),
+			[]byte(`{"window":{"start":"2023-01-01T00:00:00Z","end":"2023-01-01T00:01:00Z"},"count":3,"latency_avg":4,"latency_p50":3}`),
+			[]byte(`{"window":{"start":"2023-01-01T00:00:30Z","end":"2023-01-01T00:01:30Z"},"count":2,"latency_avg":5.5,"latency_p50":3}`),
+		},
+		nil,
+	},
+}
+
+func TestWindow(t *testing.T) {
+	ctx := context.TODO()
+	capsule := config.NewCapsule()
+
+	for _, test := range windowTests {
+		t.Run(test.name, func(t *testing.T) {
+			var capsules []config.Capsule
+			for _, t := range test.test {
+				capsule.SetData(t)
+				capsules = append(capsules, capsule)
+			}
+
+			proc, err := newProcWindow(ctx, test.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := proc.Batch(ctx, capsules...)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatalf("expected %d, got %d", len(test.expected), len(result))
+			}
+
+			for i, res := range result {
+				if !bytes.Equal(res.Data(), test.expected[i]) {
+					t.Errorf("expected %s, got %s", test.expected[i], res.Data())
+				}
+			}
+		})
+	}
+}
+
+func TestWindowInvalidTime(t *testing.T) {
+	ctx := context.TODO()
+
+	for _, test := range []struct {
+		format string
+		data   []byte
+	}{
+		{"unix", []byte(`{"host":"foo"}`)},
+		{"unix", []byte(`{"ts":"foo"}`)},
+		{"unix_milli", []byte(`{"ts":null}`)},
+		{"", []byte(`{"host":"foo"}`)},
+	} {
+		proc, err := newProcWindow(ctx, config.Config{
+			Type: "window",
+			Settings: map[string]interface{}{
+				"options": map[string]interface{}{
+					"type":        "tumbling",
+					"size":        60,
+					"time_key":    "ts",
+					"time_format": test.format,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		capsule := config.NewCapsule()
+		capsule.SetData(test.data)
+
+		if _, err := proc.Batch(ctx, capsule); err == nil {
+			t.Errorf("expected error for format %q and data %s", test.format, test.data)
+		}
+	}
+}
+
+func benchmarkWindow(b *testing.B, batcher procWindow, capsules []config.Capsule) {
+	ctx := context.TODO()
+	for i := 0; i < b.N; i++ {
+		_, _ = batcher.Batch(ctx, capsules...)
+	}
+}
+
+func BenchmarkWindow(b *testing.B) {
+	capsule := config.NewCapsule()
+	for _, test := range windowTests {
+		var capsules []config.Capsule
+		for _, t := range test.test {
+			capsule.SetData(t)
+			capsules = append(capsules, capsule)
+		}
+
+		proc, err := newProcWindow(context.TODO(), test.cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(test.name,
+			func(b *testing.B) {
+				benchmarkWindow(b, proc, capsules)
+			},
+		)
+	}
+}